@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/bluehoodie/smoke/internal/openapi"
+
+	"gopkg.in/yaml.v2"
+)
+
+type generateCommand struct {
+	FromOpenAPI string `long:"from-openapi" required:"true" description:"OpenAPI 3 spec, in YAML or JSON, to generate the test from"`
+	Output      string `short:"o" long:"output" description:"file to write the generated test to (default: stdout)"`
+}
+
+// Execute generates a YAML test file with one contract per operation of an OpenAPI spec
+func (c *generateCommand) Execute(args []string) error {
+	spec, err := openapi.Load(c.FromOpenAPI)
+	if err != nil {
+		return err
+	}
+
+	t, err := openapi.Generate(spec)
+	if err != nil {
+		return fmt.Errorf("could not generate test from %v: %v", c.FromOpenAPI, err)
+	}
+
+	data, err := yaml.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("could not marshal generated test: %v", err)
+	}
+
+	if c.Output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	return ioutil.WriteFile(c.Output, data, 0644)
+}
@@ -0,0 +1,665 @@
+// Package jsonschema validates decoded JSON documents against a JSON Schema.
+//
+// The supported keywords are those of JSON Schema draft 7 which describe the structure of a document: type, enum,
+// const, properties, required, additionalProperties, patternProperties, minProperties, maxProperties, items,
+// additionalItems, minItems, maxItems, uniqueItems, minLength, maxLength, pattern, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, multipleOf, allOf, anyOf, oneOf and not, as well as the nullable keyword of
+// OpenAPI.  $ref is supported for references within the same document.  format is not validated.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes a part of a document which does not match its schema
+type ValidationError struct {
+	// Pointer is the JSON pointer, as a URI fragment, of the value which does not match
+	Pointer string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pointer, e.Message)
+}
+
+// Schema is a compiled JSON schema
+type Schema struct {
+	root     interface{}
+	node     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// New compiles a schema.  The schema is a decoded JSON or YAML document.
+func New(schema interface{}) (*Schema, error) {
+	schema = Normalize(schema)
+	return compile(schema, schema)
+}
+
+// NewWithRoot compiles the schema node found within a larger document, such as an OpenAPI specification, against
+// which its references are resolved
+func NewWithRoot(node, root interface{}) (*Schema, error) {
+	return compile(Normalize(node), Normalize(root))
+}
+
+func compile(node, root interface{}) (*Schema, error) {
+	s := &Schema{root: root, node: node, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.check(node, "#", make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// check verifies the structure of a schema node and compiles its regular expressions
+func (s *Schema) check(node interface{}, location string, seen map[string]bool) error {
+	switch n := node.(type) {
+	case bool:
+		return nil
+	case map[string]interface{}:
+		if err := s.checkLoop(n, location, nil); err != nil {
+			return err
+		}
+
+		if ref, ok := n["$ref"]; ok {
+			refString, ok := ref.(string)
+			if !ok {
+				return fmt.Errorf("%s: $ref must be a string", location)
+			}
+			if seen[refString] {
+				return nil
+			}
+			seen[refString] = true
+			target, err := s.resolve(refString)
+			if err != nil {
+				return fmt.Errorf("%s: %v", location, err)
+			}
+			return s.check(target, refString, seen)
+		}
+
+		if p, ok := n["pattern"]; ok {
+			if err := s.compilePattern(p, location+"/pattern"); err != nil {
+				return err
+			}
+		}
+
+		if pp, ok := n["patternProperties"].(map[string]interface{}); ok {
+			for p, sub := range pp {
+				if err := s.compilePattern(p, location+"/patternProperties"); err != nil {
+					return err
+				}
+				if err := s.check(sub, location+"/patternProperties/"+escape(p), seen); err != nil {
+					return err
+				}
+			}
+		}
+
+		for _, key := range []string{"properties", "definitions", "$defs"} {
+			if props, ok := n[key].(map[string]interface{}); ok {
+				for name, sub := range props {
+					if err := s.check(sub, location+"/"+key+"/"+escape(name), seen); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		for _, key := range []string{"additionalProperties", "additionalItems", "not"} {
+			if sub, ok := n[key]; ok {
+				if err := s.check(sub, location+"/"+key, seen); err != nil {
+					return err
+				}
+			}
+		}
+
+		if items, ok := n["items"]; ok {
+			if list, ok := items.([]interface{}); ok {
+				for i, sub := range list {
+					if err := s.check(sub, fmt.Sprintf("%s/items/%d", location, i), seen); err != nil {
+						return err
+					}
+				}
+			} else if err := s.check(items, location+"/items", seen); err != nil {
+				return err
+			}
+		}
+
+		for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+			if sub, ok := n[key]; ok {
+				list, ok := sub.([]interface{})
+				if !ok {
+					return fmt.Errorf("%s/%s: must be an array of schemas", location, key)
+				}
+				for i, item := range list {
+					if err := s.check(item, fmt.Sprintf("%s/%s/%d", location, key, i), seen); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%s: a schema must be an object or a boolean", location)
+}
+
+// checkLoop fails when a schema node applies to a value, through $ref, allOf, anyOf, oneOf and not, a schema which
+// applies the node again to the same value, since validating that value would never end.  References through
+// properties or items are fine, as they apply to a smaller value.  stack holds the locations applied so far.
+func (s *Schema) checkLoop(node interface{}, location string, stack []string) error {
+	for _, l := range stack {
+		if l == location {
+			return fmt.Errorf("%s: circular reference to %s", stack[len(stack)-1], location)
+		}
+	}
+
+	n, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	stack = append(stack, location)
+
+	if ref, ok := n["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			// reported by check
+			return nil
+		}
+		return s.checkLoop(target, ref, stack)
+	}
+
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		list, _ := n[key].([]interface{})
+		for i, sub := range list {
+			if err := s.checkLoop(sub, fmt.Sprintf("%s/%s/%d", location, key, i), stack); err != nil {
+				return err
+			}
+		}
+	}
+	if not, ok := n["not"]; ok {
+		return s.checkLoop(not, location+"/not", stack)
+	}
+
+	return nil
+}
+
+func (s *Schema) compilePattern(p interface{}, location string) error {
+	pattern, ok := p.(string)
+	if !ok {
+		return fmt.Errorf("%s: must be a string", location)
+	}
+	if _, ok := s.patterns[pattern]; ok {
+		return nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("%s: invalid regular expression: %v", location, err)
+	}
+	s.patterns[pattern] = re
+	return nil
+}
+
+// resolve returns the node of the root document referenced by a local reference such as #/definitions/user
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported reference %q: only references within the same document are supported", ref)
+	}
+
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %v", ref, err)
+	}
+
+	node := s.root
+	if fragment == "" {
+		return node, nil
+	}
+
+	for _, token := range strings.Split(strings.TrimPrefix(fragment, "/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			next, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("reference %q not found", ref)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("reference %q not found", ref)
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("reference %q not found", ref)
+		}
+	}
+
+	return node, nil
+}
+
+// Validate returns every part of doc which does not match the schema, or nil if doc is valid.
+// doc is a decoded JSON document, with numbers as float64 or json.Number.
+func (s *Schema) Validate(doc interface{}) []ValidationError {
+	v := &validator{schema: s}
+	v.validate(s.node, doc, "#")
+	return v.errors
+}
+
+type validator struct {
+	schema *Schema
+	errors []ValidationError
+}
+
+func (v *validator) fail(pointer, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+}
+
+// valid reports whether doc matches node, without recording errors
+func (v *validator) valid(node, doc interface{}, pointer string) bool {
+	sub := &validator{schema: v.schema}
+	sub.validate(node, doc, pointer)
+	return len(sub.errors) == 0
+}
+
+func (v *validator) validate(node, doc interface{}, pointer string) {
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			v.fail(pointer, "no value is allowed")
+		}
+		return
+	case map[string]interface{}:
+		v.validateObject(n, doc, pointer)
+	}
+}
+
+func (v *validator) validateObject(n map[string]interface{}, doc interface{}, pointer string) {
+	if ref, ok := n["$ref"].(string); ok {
+		target, err := v.schema.resolve(ref)
+		if err != nil {
+			v.fail(pointer, "%v", err)
+			return
+		}
+		v.validate(target, doc, pointer)
+		return
+	}
+
+	if doc == nil {
+		if nullable, _ := n["nullable"].(bool); nullable {
+			return
+		}
+	}
+
+	if t, ok := n["type"]; ok && !matchesType(t, doc) {
+		v.fail(pointer, "expected %s, got %s", describeType(t), typeOf(doc))
+		return
+	}
+
+	if enum, ok := n["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if equal(e, doc) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(pointer, "value %s is not one of %s", format(doc), format(enum))
+		}
+	}
+
+	if c, ok := n["const"]; ok && !equal(c, doc) {
+		v.fail(pointer, "expected %s, got %s", format(c), format(doc))
+	}
+
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		v.validateProperties(n, d, pointer)
+	case []interface{}:
+		v.validateItems(n, d, pointer)
+	case string:
+		v.validateString(n, d, pointer)
+	default:
+		if f, ok := toFloat(doc); ok {
+			v.validateNumber(n, f, pointer)
+		}
+	}
+
+	if all, ok := n["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			v.validate(sub, doc, pointer)
+		}
+	}
+
+	if any, ok := n["anyOf"].([]interface{}); ok {
+		matched := false
+		for _, sub := range any {
+			if v.valid(sub, doc, pointer) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(pointer, "does not match any of the schemas in anyOf")
+		}
+	}
+
+	if one, ok := n["oneOf"].([]interface{}); ok {
+		matched := 0
+		for _, sub := range one {
+			if v.valid(sub, doc, pointer) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			v.fail(pointer, "must match exactly one of the schemas in oneOf, matched %d", matched)
+		}
+	}
+
+	if not, ok := n["not"]; ok && v.valid(not, doc, pointer) {
+		v.fail(pointer, "must not match the schema in not")
+	}
+}
+
+func (v *validator) validateProperties(n map[string]interface{}, d map[string]interface{}, pointer string) {
+	if required, ok := n["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := d[name]; !ok {
+				v.fail(pointer, "missing required property %q", name)
+			}
+		}
+	}
+
+	if min, ok := toInt(n["minProperties"]); ok && len(d) < min {
+		v.fail(pointer, "expected at least %d properties, got %d", min, len(d))
+	}
+	if max, ok := toInt(n["maxProperties"]); ok && len(d) > max {
+		v.fail(pointer, "expected at most %d properties, got %d", max, len(d))
+	}
+
+	properties, _ := n["properties"].(map[string]interface{})
+	patternProperties, _ := n["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := n["additionalProperties"]
+
+	for _, name := range sortedKeys(d) {
+		value := d[name]
+		child := pointer + "/" + escape(name)
+
+		matched := false
+		if sub, ok := properties[name]; ok {
+			matched = true
+			v.validate(sub, value, child)
+		}
+		for pattern, sub := range patternProperties {
+			if re := v.schema.patterns[pattern]; re != nil && re.MatchString(name) {
+				matched = true
+				v.validate(sub, value, child)
+			}
+		}
+
+		if matched || !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok {
+			if !allowed {
+				v.fail(child, "additional property %q is not allowed", name)
+			}
+			continue
+		}
+		v.validate(additional, value, child)
+	}
+}
+
+func (v *validator) validateItems(n map[string]interface{}, d []interface{}, pointer string) {
+	if min, ok := toInt(n["minItems"]); ok && len(d) < min {
+		v.fail(pointer, "expected at least %d items, got %d", min, len(d))
+	}
+	if max, ok := toInt(n["maxItems"]); ok && len(d) > max {
+		v.fail(pointer, "expected at most %d items, got %d", max, len(d))
+	}
+
+	if unique, _ := n["uniqueItems"].(bool); unique {
+		for i := range d {
+			for j := i + 1; j < len(d); j++ {
+				if equal(d[i], d[j]) {
+					v.fail(fmt.Sprintf("%s/%d", pointer, j), "duplicate of item %d", i)
+				}
+			}
+		}
+	}
+
+	switch items := n["items"].(type) {
+	case nil:
+	case []interface{}:
+		for i, value := range d {
+			child := fmt.Sprintf("%s/%d", pointer, i)
+			if i < len(items) {
+				v.validate(items[i], value, child)
+				continue
+			}
+			if additional, ok := n["additionalItems"]; ok {
+				if allowed, ok := additional.(bool); ok {
+					if !allowed {
+						v.fail(child, "additional items are not allowed")
+					}
+					continue
+				}
+				v.validate(additional, value, child)
+			}
+		}
+	default:
+		for i, value := range d {
+			v.validate(items, value, fmt.Sprintf("%s/%d", pointer, i))
+		}
+	}
+}
+
+func (v *validator) validateString(n map[string]interface{}, d string, pointer string) {
+	length := utf8.RuneCountInString(d)
+	if min, ok := toInt(n["minLength"]); ok && length < min {
+		v.fail(pointer, "expected a length of at least %d, got %d", min, length)
+	}
+	if max, ok := toInt(n["maxLength"]); ok && length > max {
+		v.fail(pointer, "expected a length of at most %d, got %d", max, length)
+	}
+	if pattern, ok := n["pattern"].(string); ok {
+		if re := v.schema.patterns[pattern]; re != nil && !re.MatchString(d) {
+			v.fail(pointer, "%s does not match pattern %s", format(d), pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(n map[string]interface{}, d float64, pointer string) {
+	if min, ok := toFloat(n["minimum"]); ok {
+		if exclusive, _ := n["exclusiveMinimum"].(bool); exclusive && d <= min {
+			v.fail(pointer, "expected a value greater than %v, got %v", min, d)
+		} else if d < min {
+			v.fail(pointer, "expected a value of at least %v, got %v", min, d)
+		}
+	}
+	if max, ok := toFloat(n["maximum"]); ok {
+		if exclusive, _ := n["exclusiveMaximum"].(bool); exclusive && d >= max {
+			v.fail(pointer, "expected a value less than %v, got %v", max, d)
+		} else if d > max {
+			v.fail(pointer, "expected a value of at most %v, got %v", max, d)
+		}
+	}
+	if min, ok := toFloat(n["exclusiveMinimum"]); ok && d <= min {
+		v.fail(pointer, "expected a value greater than %v, got %v", min, d)
+	}
+	if max, ok := toFloat(n["exclusiveMaximum"]); ok && d >= max {
+		v.fail(pointer, "expected a value less than %v, got %v", max, d)
+	}
+	if multiple, ok := toFloat(n["multipleOf"]); ok && multiple > 0 {
+		if q := d / multiple; math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(pointer, "expected a multiple of %v, got %v", multiple, d)
+		}
+	}
+}
+
+func matchesType(t interface{}, doc interface{}) bool {
+	switch tt := t.(type) {
+	case string:
+		return isType(tt, doc)
+	case []interface{}:
+		for _, candidate := range tt {
+			if s, ok := candidate.(string); ok && isType(s, doc) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(t string, doc interface{}) bool {
+	switch t {
+	case "integer":
+		f, ok := toFloat(doc)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := toFloat(doc)
+		return ok
+	}
+	return typeOf(doc) == t
+}
+
+func describeType(t interface{}) string {
+	if list, ok := t.([]interface{}); ok {
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func typeOf(doc interface{}) string {
+	switch doc.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	if _, ok := toFloat(doc); ok {
+		return "number"
+	}
+	return fmt.Sprintf("%T", doc)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func toInt(v interface{}) (int, bool) {
+	f, ok := toFloat(v)
+	return int(f), ok
+}
+
+func equal(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+
+	switch av := a.(type) {
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if w, ok := bv[k]; !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func format(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func escape(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Normalize converts a document decoded from YAML, whose maps have interface{} keys, to the types used by
+// encoding/json.  Documents which are already using those types are returned unchanged.
+func Normalize(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			m[fmt.Sprint(k)] = Normalize(v)
+		}
+		return m
+	case map[string]interface{}:
+		for k, v := range val {
+			val[k] = Normalize(v)
+		}
+		return val
+	case []interface{}:
+		for i, v := range val {
+			val[i] = Normalize(v)
+		}
+		return val
+	}
+	return v
+}
@@ -0,0 +1,194 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const userSchema = `
+type: object
+required: [id, name, email]
+additionalProperties: false
+properties:
+  id: {type: integer, minimum: 1}
+  name: {type: string, minLength: 1, maxLength: 10}
+  email: {type: string, pattern: "^[^@]+@[^@]+$"}
+  role: {enum: [admin, user]}
+  tags:
+    type: array
+    uniqueItems: true
+    maxItems: 2
+    items: {type: string}
+  manager: {$ref: "#/definitions/manager"}
+definitions:
+  manager:
+    type: [object, "null"]
+    required: [id]
+    properties:
+      id: {type: integer}
+`
+
+var validationTests = []struct {
+	document    string
+	expected    []string
+	description string
+}{
+	{
+		document:    `{"id": 1, "name": "ann", "email": "ann@example.com", "role": "admin", "tags": ["a"], "manager": {"id": 2}}`,
+		description: "should accept a valid document",
+	},
+	{
+		document:    `{"id": 1, "name": "ann", "email": "ann@example.com", "manager": null}`,
+		description: "should accept one of several types",
+	},
+	{
+		document: `{"id": 0, "name": "a very long name", "email": "nope", "role": "guest", "tags": ["a", "a", "b"], "manager": {"id": "x"}, "extra": true}`,
+		expected: []string{
+			`#/email: "nope" does not match pattern ^[^@]+@[^@]+$`,
+			`#/extra: additional property "extra" is not allowed`,
+			`#/id: expected a value of at least 1, got 0`,
+			`#/manager/id: expected integer, got string`,
+			`#/name: expected a length of at most 10, got 16`,
+			`#/role: value "guest" is not one of ["admin","user"]`,
+			`#/tags: expected at most 2 items, got 3`,
+			`#/tags/1: duplicate of item 0`,
+		},
+		description: "should report every violation with its pointer",
+	},
+	{
+		document:    `{"name": "ann"}`,
+		expected:    []string{`#: missing required property "id"`, `#: missing required property "email"`},
+		description: "should report every missing property",
+	},
+	{
+		document:    `[1, 2]`,
+		expected:    []string{`#: expected object, got array`},
+		description: "should report a wrong type at the root",
+	},
+}
+
+func TestValidate(t *testing.T) {
+	var raw interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(userSchema), &raw))
+
+	schema, err := New(raw)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, tt := range validationTests {
+		var doc interface{}
+		assert.NoError(t, json.Unmarshal([]byte(tt.document), &doc), tt.description)
+
+		var errs []string
+		for _, e := range schema.Validate(doc) {
+			errs = append(errs, e.Error())
+		}
+
+		assert.Equal(t, tt.expected, errs, tt.description)
+	}
+}
+
+var combinatorTests = []struct {
+	schema      string
+	document    string
+	valid       bool
+	description string
+}{
+	{`{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `3`, true, "anyOf should accept a value matching one schema"},
+	{`{"anyOf": [{"type": "string"}, {"type": "integer"}]}`, `3.5`, false, "anyOf should reject a value matching no schema"},
+	{`{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `3`, false, "oneOf should reject a value matching two schemas"},
+	{`{"allOf": [{"minimum": 1}, {"maximum": 5}]}`, `6`, false, "allOf should reject a value failing one schema"},
+	{`{"not": {"type": "null"}}`, `null`, false, "not should reject a value matching its schema"},
+	{`{"const": {"a": [1]}}`, `{"a": [1.0]}`, true, "const should compare numbers by value"},
+	{`{"exclusiveMinimum": 1}`, `1`, false, "exclusiveMinimum should reject its own value"},
+	{`{"minimum": 1, "exclusiveMinimum": true}`, `1`, false, "boolean exclusiveMinimum should reject the minimum"},
+	{`{"multipleOf": 0.1}`, `0.3`, true, "multipleOf should tolerate floating point errors"},
+	{`{"type": "string", "nullable": true}`, `null`, true, "nullable should accept null"},
+	{`{"items": [{"type": "string"}], "additionalItems": false}`, `["a", 1]`, false, "additionalItems should reject extra items"},
+	{`{"patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false}`, `{"x-a": "b"}`, true, "patternProperties should validate matching properties"},
+	{`false`, `1`, false, "the false schema should reject everything"},
+}
+
+func TestCombinators(t *testing.T) {
+	for _, tt := range combinatorTests {
+		var raw, doc interface{}
+		assert.NoError(t, json.Unmarshal([]byte(tt.schema), &raw), tt.description)
+		assert.NoError(t, json.Unmarshal([]byte(tt.document), &doc), tt.description)
+
+		schema, err := New(raw)
+		if assert.NoError(t, err, tt.description) {
+			assert.Equal(t, tt.valid, len(schema.Validate(doc)) == 0, tt.description)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, schema := range []string{
+		`{"pattern": "[a-"}`,
+		`{"properties": {"a": {"$ref": "#/definitions/missing"}}}`,
+		`{"$ref": "other.json#/definitions/a"}`,
+		`{"allOf": {"type": "string"}}`,
+		`"string"`,
+	} {
+		var raw interface{}
+		assert.NoError(t, json.Unmarshal([]byte(schema), &raw))
+
+		_, err := New(raw)
+		assert.Error(t, err, "%s should not compile", schema)
+	}
+}
+
+func TestRecursiveReference(t *testing.T) {
+	var raw, doc interface{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"type": "object", "properties": {"child": {"$ref": "#"}}, "required": ["id"]}`), &raw))
+	assert.NoError(t, json.Unmarshal([]byte(`{"id": 1, "child": {"id": 2, "child": {}}}`), &doc))
+
+	schema, err := New(raw)
+	if assert.NoError(t, err) {
+		errs := schema.Validate(doc)
+		if assert.Len(t, errs, 1) {
+			assert.Equal(t, `#/child/child: missing required property "id"`, errs[0].Error())
+		}
+	}
+}
+
+func TestCircularReference(t *testing.T) {
+	tests := []struct {
+		schema      string
+		err         string
+		description string
+	}{
+		{
+			schema:      `{"$ref": "#"}`,
+			err:         "#: circular reference to #",
+			description: "a schema referencing itself should not compile",
+		},
+		{
+			schema:      `{"properties": {"a": {"$ref": "#/definitions/a"}}, "definitions": {"a": {"$ref": "#/definitions/a"}}}`,
+			err:         "#/definitions/a: circular reference to #/definitions/a",
+			description: "a definition referencing itself should not compile",
+		},
+		{
+			schema:      `{"definitions": {"a": {"$ref": "#/definitions/b"}, "b": {"anyOf": [{"type": "string"}, {"$ref": "#/definitions/a"}]}}, "$ref": "#/definitions/a"}`,
+			err:         "#/definitions/b/anyOf/1: circular reference to #/definitions/a",
+			description: "definitions referencing each other through a combinator should not compile",
+		},
+		{
+			schema:      `{"definitions": {"a": {"$ref": "#/definitions/%61"}}, "$ref": "#/definitions/a"}`,
+			err:         "#/definitions/%61: circular reference to #/definitions/%61",
+			description: "a definition referencing itself with another spelling should not compile",
+		},
+	}
+
+	for _, tt := range tests {
+		var raw interface{}
+		assert.NoError(t, json.Unmarshal([]byte(tt.schema), &raw), tt.description)
+
+		_, err := New(raw)
+		assert.EqualError(t, err, tt.err, tt.description)
+	}
+}
@@ -0,0 +1,294 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bluehoodie/smoke/internal/jsonschema"
+	"github.com/bluehoodie/smoke/tester"
+	"github.com/pkg/errors"
+)
+
+var (
+	pathParamRegex    = regexp.MustCompile(`\{([^}]+)\}`)
+	nonWordRegex      = regexp.MustCompile(`\W+`)
+	preferredContents = []string{"application/json", "application/x-www-form-urlencoded", "text/plain"}
+)
+
+// Generate returns a Test with one Contract per operation of the spec.
+//
+// Path parameters, required query parameters and required header parameters are filled with ::variables::, whose
+// example values, when the spec has some, are added to the globals of the Test.
+func Generate(spec *Spec) (*tester.Test, error) {
+	ops, err := spec.Operations()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &tester.Test{
+		Name:    spec.Info.Title,
+		Globals: make(map[string]string),
+	}
+
+	for _, op := range ops {
+		contract, err := generateContract(spec, op, t.Globals)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s %s", strings.ToUpper(op.Method), op.Path)
+		}
+		t.Contracts = append(t.Contracts, contract)
+	}
+
+	if len(t.Globals) == 0 {
+		t.Globals = nil
+	}
+
+	return t, nil
+}
+
+func generateContract(spec *Spec, op OperationRef, globals map[string]string) (tester.Contract, error) {
+	contract := tester.Contract{
+		Name:   contractName(op),
+		Method: strings.ToUpper(op.Method),
+	}
+
+	params := make(map[string]Parameter)
+	var query []string
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			params[p.Name] = p
+		case "query":
+			if p.Required {
+				query = append(query, fmt.Sprintf("%s=::%s::", p.Name, variableName(p.Name)))
+				addExample(globals, p)
+			}
+		case "header":
+			if p.Required {
+				if contract.Headers == nil {
+					contract.Headers = make(map[string]string)
+				}
+				contract.Headers[p.Name] = fmt.Sprintf("::%s::", variableName(p.Name))
+				addExample(globals, p)
+			}
+		}
+	}
+
+	contract.Path = pathParamRegex.ReplaceAllStringFunc(op.Path, func(match string) string {
+		name := match[1 : len(match)-1]
+		if p, ok := params[name]; ok {
+			addExample(globals, p)
+		}
+		return fmt.Sprintf("::%s::", variableName(name))
+	})
+	if len(query) > 0 {
+		contract.Path += "?" + strings.Join(query, "&")
+	}
+
+	body, contentType, err := exampleBody(spec, op.Operation.RequestBody)
+	if err != nil {
+		return contract, err
+	}
+	if body != "" {
+		contract.Body = body
+		if contract.Headers == nil {
+			contract.Headers = make(map[string]string)
+		}
+		if _, ok := contract.Headers["Content-Type"]; !ok {
+			contract.Headers["Content-Type"] = contentType
+		}
+	}
+
+	contract.ExpectedHTTPCode = successCode(op.Operation.Responses)
+
+	return contract, nil
+}
+
+// contractName returns the operationId of the operation, or a name made of its method and path
+func contractName(op OperationRef) string {
+	if op.Operation.OperationID != "" {
+		return op.Operation.OperationID
+	}
+	return op.Method + strings.TrimRight(nonWordRegex.ReplaceAllString(op.Path, "_"), "_")
+}
+
+// variableName returns a name usable as a ::variable:: for a parameter
+func variableName(name string) string {
+	return strings.Trim(nonWordRegex.ReplaceAllString(name, "_"), "_")
+}
+
+func addExample(globals map[string]string, p Parameter) {
+	example := p.Example
+	if example == nil {
+		if schema, ok := p.Schema.(map[interface{}]interface{}); ok {
+			example = schema["example"]
+			if example == nil {
+				example = schema["default"]
+			}
+		}
+	}
+	if example == nil {
+		return
+	}
+
+	globals[variableName(p.Name)] = formatValue(example)
+}
+
+// exampleBody returns an example request body and its content type, preferring JSON bodies
+func exampleBody(spec *Spec, body *RequestBody) (string, string, error) {
+	body, err := spec.resolveRequestBody(body)
+	if err != nil || body == nil || len(body.Content) == 0 {
+		return "", "", err
+	}
+
+	contentType := ""
+	for _, preferred := range preferredContents {
+		if _, ok := body.Content[preferred]; ok {
+			contentType = preferred
+			break
+		}
+	}
+	if contentType == "" {
+		types := make([]string, 0, len(body.Content))
+		for t := range body.Content {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		contentType = types[0]
+	}
+
+	media := body.Content[contentType]
+
+	example := media.Example
+	if example == nil && len(media.Examples) > 0 {
+		names := make([]string, 0, len(media.Examples))
+		for name := range media.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		e, err := spec.resolveExample(media.Examples[names[0]])
+		if err != nil {
+			return "", "", err
+		}
+		example = e.Value
+	}
+	if example == nil {
+		example = exampleFromSchema(spec, media.Schema, 0)
+	}
+	if example == nil {
+		return "", contentType, nil
+	}
+
+	if s, ok := example.(string); ok && !strings.Contains(contentType, "json") {
+		return s, contentType, nil
+	}
+
+	data, err := json.Marshal(jsonschema.Normalize(example))
+	if err != nil {
+		return "", "", errors.Wrap(err, "could not encode example request body")
+	}
+	return string(data), contentType, nil
+}
+
+// exampleFromSchema builds an example value from the examples, defaults and types declared in a schema
+func exampleFromSchema(spec *Spec, node interface{}, depth int) interface{} {
+	schema, ok := spec.resolveSchema(node).(map[string]interface{})
+	if !ok || depth > 8 {
+		return nil
+	}
+
+	for _, key := range []string{"example", "default", "const"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, key := range []string{"allOf", "oneOf", "anyOf"} {
+		if list, ok := schema[key].([]interface{}); ok && len(list) > 0 {
+			if key != "allOf" {
+				return exampleFromSchema(spec, list[0], depth+1)
+			}
+			merged := make(map[string]interface{})
+			for _, sub := range list {
+				if m, ok := exampleFromSchema(spec, sub, depth+1).(map[string]interface{}); ok {
+					for k, v := range m {
+						merged[k] = v
+					}
+				}
+			}
+			return merged
+		}
+	}
+
+	t, _ := schema["type"].(string)
+	if t == "" {
+		if _, ok := schema["properties"]; ok {
+			t = "object"
+		}
+	}
+
+	switch t {
+	case "object":
+		obj := make(map[string]interface{})
+		props, _ := schema["properties"].(map[string]interface{})
+		for name, sub := range props {
+			if v := exampleFromSchema(spec, sub, depth+1); v != nil {
+				obj[name] = v
+			}
+		}
+		return obj
+	case "array":
+		if item := exampleFromSchema(spec, schema["items"], depth+1); item != nil {
+			return []interface{}{item}
+		}
+		return []interface{}{}
+	case "string":
+		return "string"
+	case "integer":
+		return 0
+	case "number":
+		return 0.0
+	case "boolean":
+		return false
+	}
+
+	return nil
+}
+
+// successCode returns the first 2xx response code of an operation, or 0 if it has none
+func successCode(responses map[string]Response) int {
+	var codes []int
+	for code := range responses {
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 {
+			codes = append(codes, n)
+		}
+	}
+	if len(codes) == 0 {
+		if _, ok := responses["2XX"]; ok {
+			return 200
+		}
+		return 0
+	}
+	sort.Ints(codes)
+	return codes[0]
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int, int64, uint64, float64, bool:
+		return fmt.Sprint(val)
+	}
+	data, err := json.Marshal(jsonschema.Normalize(v))
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
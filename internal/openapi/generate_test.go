@@ -0,0 +1,235 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bluehoodie/smoke/tester"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const petstore = `
+openapi: 3.0.0
+info:
+  title: Petstore
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: limit
+          in: query
+          required: true
+          schema: {type: integer, example: 10}
+        - name: offset
+          in: query
+          schema: {type: integer}
+        - $ref: "#/components/parameters/RequestID"
+      responses:
+        "200":
+          description: ok
+        default:
+          description: error
+    post:
+      requestBody:
+        $ref: "#/components/requestBodies/Pet"
+      responses:
+        "201":
+          description: created
+        "202":
+          description: accepted
+  /pets/{pet-id}:
+    parameters:
+      - name: pet-id
+        in: path
+        required: true
+        example: 42
+    get:
+      operationId: showPetById
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+    put:
+      operationId: updatePet
+      requestBody:
+        content:
+          application/json:
+            examples:
+              rex:
+                $ref: "#/components/examples/Rex"
+      responses:
+        "204":
+          description: updated
+components:
+  parameters:
+    RequestID:
+      name: X-Request-ID
+      in: header
+      required: true
+  requestBodies:
+    Pet:
+      content:
+        application/json:
+          schema: {$ref: "#/components/schemas/Pet"}
+  examples:
+    Rex:
+      value: {name: rex, tag: dog}
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, example: tom}
+        tag: {type: string}
+        age: {type: integer}
+`
+
+func TestGenerate(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	test, err := Generate(spec)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "Petstore", test.Name)
+	assert.Equal(t, map[string]string{"limit": "10", "pet_id": "42"}, test.Globals)
+	assert.Equal(t, []tester.Contract{
+		{
+			Name:             "listPets",
+			Path:             "/pets?limit=::limit::",
+			Method:           "GET",
+			Headers:          map[string]string{"X-Request-ID": "::X_Request_ID::"},
+			ExpectedHTTPCode: 200,
+		},
+		{
+			Name:             "post_pets",
+			Path:             "/pets",
+			Method:           "POST",
+			Body:             `{"age":0,"name":"tom","tag":"string"}`,
+			Headers:          map[string]string{"Content-Type": "application/json"},
+			ExpectedHTTPCode: 201,
+		},
+		{
+			Name:             "showPetById",
+			Path:             "/pets/::pet_id::",
+			Method:           "GET",
+			ExpectedHTTPCode: 200,
+		},
+		{
+			Name:             "updatePet",
+			Path:             "/pets/::pet_id::",
+			Method:           "PUT",
+			Body:             `{"name":"rex","tag":"dog"}`,
+			Headers:          map[string]string{"Content-Type": "application/json"},
+			ExpectedHTTPCode: 204,
+		},
+	}, test.Contracts)
+}
+
+func TestGenerateRoundTrip(t *testing.T) {
+	spec, err := Parse([]byte(petstore))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	test, err := Generate(spec)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	generated, err := yaml.Marshal(test)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "smoke")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "generated.yaml")
+	assert.NoError(t, ioutil.WriteFile(file, generated, 0644))
+
+	loaded, err := tester.NewTest(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	reloaded, err := yaml.Marshal(loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, string(generated), string(reloaded))
+}
+
+func TestParseVersion(t *testing.T) {
+	_, err := Parse([]byte("swagger: '2.0'\n"))
+	assert.Error(t, err, "swagger 2 specs should be rejected")
+}
+
+func TestGenerateCircularReference(t *testing.T) {
+	tests := []struct {
+		spec        string
+		err         string
+		description string
+	}{
+		{
+			spec: `
+openapi: 3.0.0
+info: {title: loop, version: 1.0.0}
+paths:
+  /pets:
+    get:
+      parameters:
+        - $ref: '#/components/parameters/P'
+      responses:
+        '200': {description: ok}
+components:
+  parameters:
+    P: {$ref: '#/components/parameters/P'}
+`,
+			err:         "circular reference to #/components/parameters/P",
+			description: "a parameter referencing itself should be reported",
+		},
+		{
+			spec: `
+openapi: 3.0.0
+info: {title: loop, version: 1.0.0}
+paths:
+  /pets:
+    post:
+      requestBody: {$ref: '#/components/requestBodies/A'}
+      responses:
+        '200': {description: ok}
+components:
+  requestBodies:
+    A: {$ref: '#/components/requestBodies/B'}
+    B: {$ref: '#/components/requestBodies/A'}
+`,
+			err:         "circular reference to #/components/requestBodies/A",
+			description: "request bodies referencing each other should be reported",
+		},
+	}
+
+	for _, tt := range tests {
+		spec, err := Parse([]byte(tt.spec))
+		if !assert.NoError(t, err, tt.description) {
+			continue
+		}
+
+		_, err = Generate(spec)
+		if assert.Error(t, err, tt.description) {
+			assert.Contains(t, err.Error(), tt.err, tt.description)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Package openapi reads OpenAPI 3 specifications, to generate smoke tests from them and to check that responses
+// conform to them.
+package openapi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/bluehoodie/smoke/internal/jsonschema"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// methods lists the operations of a path item, in the order they are generated
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// Spec is the subset of an OpenAPI 3 document used by smoke
+type Spec struct {
+	OpenAPI    string              `yaml:"openapi"`
+	Info       Info                `yaml:"info"`
+	Paths      map[string]PathItem `yaml:"paths"`
+	Components Components          `yaml:"components"`
+
+	// raw is the whole document, against which schema references are resolved
+	raw interface{}
+}
+
+// Info holds the metadata of the API
+type Info struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+// PathItem holds the operations available on a single path
+type PathItem struct {
+	Parameters []Parameter `yaml:"parameters"`
+
+	Get     *Operation `yaml:"get"`
+	Put     *Operation `yaml:"put"`
+	Post    *Operation `yaml:"post"`
+	Delete  *Operation `yaml:"delete"`
+	Options *Operation `yaml:"options"`
+	Head    *Operation `yaml:"head"`
+	Patch   *Operation `yaml:"patch"`
+	Trace   *Operation `yaml:"trace"`
+}
+
+// Operation describes a single API operation on a path
+type Operation struct {
+	OperationID string              `yaml:"operationId"`
+	Summary     string              `yaml:"summary"`
+	Parameters  []Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody        `yaml:"requestBody"`
+	Responses   map[string]Response `yaml:"responses"`
+}
+
+// Parameter describes a single operation parameter
+type Parameter struct {
+	Ref      string      `yaml:"$ref"`
+	Name     string      `yaml:"name"`
+	In       string      `yaml:"in"`
+	Required bool        `yaml:"required"`
+	Schema   interface{} `yaml:"schema"`
+	Example  interface{} `yaml:"example"`
+}
+
+// RequestBody describes a single request body
+type RequestBody struct {
+	Ref      string               `yaml:"$ref"`
+	Required bool                 `yaml:"required"`
+	Content  map[string]MediaType `yaml:"content"`
+}
+
+// MediaType describes the schema and examples of a body for a given content type
+type MediaType struct {
+	Schema   interface{}        `yaml:"schema"`
+	Example  interface{}        `yaml:"example"`
+	Examples map[string]Example `yaml:"examples"`
+}
+
+// Example holds an example value
+type Example struct {
+	Ref   string      `yaml:"$ref"`
+	Value interface{} `yaml:"value"`
+}
+
+// Response describes a single response of an operation
+type Response struct {
+	Ref     string               `yaml:"$ref"`
+	Headers map[string]Header    `yaml:"headers"`
+	Content map[string]MediaType `yaml:"content"`
+}
+
+// Header describes a single response header
+type Header struct {
+	Ref      string      `yaml:"$ref"`
+	Required bool        `yaml:"required"`
+	Schema   interface{} `yaml:"schema"`
+}
+
+// Components holds the reusable objects referenced from the rest of the document
+type Components struct {
+	Parameters    map[string]Parameter   `yaml:"parameters"`
+	RequestBodies map[string]RequestBody `yaml:"requestBodies"`
+	Responses     map[string]Response    `yaml:"responses"`
+	Headers       map[string]Header      `yaml:"headers"`
+	Examples      map[string]Example     `yaml:"examples"`
+}
+
+// Load reads an OpenAPI 3 specification from a YAML or JSON file
+func Load(file string) (*Spec, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read openapi spec %v", file)
+	}
+
+	spec, err := Parse(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid openapi spec %v", file)
+	}
+
+	return spec, nil
+}
+
+// Parse parses an OpenAPI 3 specification in YAML or JSON
+func Parse(data []byte) (*Spec, error) {
+	spec := &Spec{}
+	if err := yaml.Unmarshal(data, spec); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q, expected 3.x", spec.OpenAPI)
+	}
+
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	spec.raw = jsonschema.Normalize(raw)
+
+	return spec, nil
+}
+
+// OperationRef identifies an operation of the spec
+type OperationRef struct {
+	Path      string
+	Method    string
+	Operation *Operation
+	// Parameters holds the parameters of the path item and of the operation, with references resolved
+	Parameters []Parameter
+}
+
+// Operations returns every operation of the spec, sorted by path and method
+func (s *Spec) Operations() ([]OperationRef, error) {
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var ops []OperationRef
+	for _, path := range paths {
+		item := s.Paths[path]
+		for _, method := range methods {
+			op := item.operation(method)
+			if op == nil {
+				continue
+			}
+
+			params, err := s.parameters(item.Parameters, op.Parameters)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s %s", strings.ToUpper(method), path)
+			}
+
+			ops = append(ops, OperationRef{Path: path, Method: method, Operation: op, Parameters: params})
+		}
+	}
+
+	return ops, nil
+}
+
+func (p PathItem) operation(method string) *Operation {
+	switch method {
+	case "get":
+		return p.Get
+	case "put":
+		return p.Put
+	case "post":
+		return p.Post
+	case "delete":
+		return p.Delete
+	case "options":
+		return p.Options
+	case "head":
+		return p.Head
+	case "patch":
+		return p.Patch
+	case "trace":
+		return p.Trace
+	}
+	return nil
+}
+
+// parameters resolves the parameters of a path item and of one of its operations.  Operation parameters override
+// path item parameters with the same name and location.
+func (s *Spec) parameters(itemParams, opParams []Parameter) ([]Parameter, error) {
+	var params []Parameter
+	index := make(map[string]int)
+
+	for _, p := range append(append([]Parameter{}, itemParams...), opParams...) {
+		resolved, err := s.resolveParameter(p)
+		if err != nil {
+			return nil, err
+		}
+
+		key := resolved.In + ":" + resolved.Name
+		if i, ok := index[key]; ok {
+			params[i] = resolved
+			continue
+		}
+		index[key] = len(params)
+		params = append(params, resolved)
+	}
+
+	return params, nil
+}
+
+func (s *Spec) resolveParameter(p Parameter) (Parameter, error) {
+	seen := make(map[string]bool)
+	for p.Ref != "" {
+		name, err := componentName(p.Ref, "parameters", seen)
+		if err != nil {
+			return p, err
+		}
+		resolved, ok := s.Components.Parameters[name]
+		if !ok {
+			return p, fmt.Errorf("reference %q not found", p.Ref)
+		}
+		p = resolved
+	}
+	return p, nil
+}
+
+func (s *Spec) resolveRequestBody(b *RequestBody) (*RequestBody, error) {
+	seen := make(map[string]bool)
+	for b != nil && b.Ref != "" {
+		name, err := componentName(b.Ref, "requestBodies", seen)
+		if err != nil {
+			return nil, err
+		}
+		resolved, ok := s.Components.RequestBodies[name]
+		if !ok {
+			return nil, fmt.Errorf("reference %q not found", b.Ref)
+		}
+		b = &resolved
+	}
+	return b, nil
+}
+
+func (s *Spec) resolveResponse(r Response) (Response, error) {
+	seen := make(map[string]bool)
+	for r.Ref != "" {
+		name, err := componentName(r.Ref, "responses", seen)
+		if err != nil {
+			return r, err
+		}
+		resolved, ok := s.Components.Responses[name]
+		if !ok {
+			return r, fmt.Errorf("reference %q not found", r.Ref)
+		}
+		r = resolved
+	}
+	return r, nil
+}
+
+func (s *Spec) resolveHeader(h Header) (Header, error) {
+	seen := make(map[string]bool)
+	for h.Ref != "" {
+		name, err := componentName(h.Ref, "headers", seen)
+		if err != nil {
+			return h, err
+		}
+		resolved, ok := s.Components.Headers[name]
+		if !ok {
+			return h, fmt.Errorf("reference %q not found", h.Ref)
+		}
+		h = resolved
+	}
+	return h, nil
+}
+
+func (s *Spec) resolveExample(e Example) (Example, error) {
+	seen := make(map[string]bool)
+	for e.Ref != "" {
+		name, err := componentName(e.Ref, "examples", seen)
+		if err != nil {
+			return e, err
+		}
+		resolved, ok := s.Components.Examples[name]
+		if !ok {
+			return e, fmt.Errorf("reference %q not found", e.Ref)
+		}
+		e = resolved
+	}
+	return e, nil
+}
+
+// componentName returns the name of the component referenced by ref, which must be of the given kind.  seen holds the
+// references followed so far to resolve a component, and ref is added to it.
+func componentName(ref, kind string, seen map[string]bool) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %q, expected a reference to %s", ref, prefix)
+	}
+	if seen[ref] {
+		return "", fmt.Errorf("circular reference to %s", ref)
+	}
+	seen[ref] = true
+	return ref[len(prefix):], nil
+}
+
+// schema compiles a schema of the spec, resolving its references against the whole document
+func (s *Spec) schema(node interface{}) (*jsonschema.Schema, error) {
+	return jsonschema.NewWithRoot(node, s.raw)
+}
+
+// resolveSchema follows the references of a schema node until it reaches an actual schema
+func (s *Spec) resolveSchema(node interface{}) interface{} {
+	node = jsonschema.Normalize(node)
+	for i := 0; i < 32; i++ {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return node
+		}
+		node = lookup(s.raw, ref)
+	}
+	return node
+}
+
+// lookup returns the node of doc referenced by a local reference such as #/components/schemas/Pet, or nil
+func lookup(doc interface{}, ref string) interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	node := doc
+	for _, token := range strings.Split(ref[2:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = m[token]
+	}
+	return node
+}
@@ -0,0 +1,331 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bluehoodie/smoke/internal/jsonschema"
+	"github.com/pkg/errors"
+)
+
+// Validator checks that responses conform to the operations of a spec: that their status code, headers and body
+// are declared for the operation matching their request.  It implements tester.ResponseValidator.
+type Validator struct {
+	spec       *Spec
+	operations []route
+
+	mu      sync.Mutex
+	schemas map[string]*jsonschema.Schema
+}
+
+// route is an operation with its path template split in segments
+type route struct {
+	OperationRef
+	segments []string
+	literals int
+}
+
+// NewValidator returns a *Validator for the operations of spec.  The responses of the operations and their schemas
+// are checked, so that an invalid spec fails here rather than when the first response is validated.
+func NewValidator(spec *Spec) (*Validator, error) {
+	ops, err := spec.Operations()
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Validator{spec: spec, schemas: make(map[string]*jsonschema.Schema)}
+	for _, op := range ops {
+		r := route{OperationRef: op, segments: splitPath(op.Path)}
+		for _, segment := range r.segments {
+			if !isTemplate(segment) {
+				r.literals++
+			}
+		}
+		v.operations = append(v.operations, r)
+
+		if err := v.check(op.Operation); err != nil {
+			return nil, errors.Wrapf(err, "%s %s", strings.ToUpper(op.Method), op.Path)
+		}
+	}
+
+	// prefer the most specific template when several match, e.g. /pets/mine over /pets/{id}
+	sort.SliceStable(v.operations, func(i, j int) bool {
+		return v.operations[i].literals > v.operations[j].literals
+	})
+
+	return v, nil
+}
+
+// ValidateResponse returns an error describing every way in which resp does not conform to the spec
+func (v *Validator) ValidateResponse(resp *http.Response, body []byte) error {
+	if resp.Request == nil {
+		return nil
+	}
+
+	method := strings.ToLower(resp.Request.Method)
+	op := v.match(method, resp.Request.URL.Path)
+	if op == nil {
+		return fmt.Errorf("openapi: no operation matches %s %s", resp.Request.Method, resp.Request.URL.Path)
+	}
+
+	var violations []string
+	for _, err := range v.validate(op, resp, body) {
+		violations = append(violations, err.Error())
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("openapi: %s %s: %s", strings.ToUpper(op.Method), op.Path, strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+// match returns the operation for a request.  When the path does not match any operation, its leading segments are
+// removed one at a time, to allow for the base path of the server.
+func (v *Validator) match(method, path string) *route {
+	segments := splitPath(path)
+	for start := 0; start <= len(segments); start++ {
+		for i := range v.operations {
+			op := &v.operations[i]
+			if op.Method == method && matchSegments(op.segments, segments[start:]) {
+				return op
+			}
+		}
+	}
+	return nil
+}
+
+func (v *Validator) validate(op *route, resp *http.Response, body []byte) []error {
+	response, ok := findResponse(op.Operation.Responses, resp.StatusCode)
+	if !ok {
+		return []error{fmt.Errorf("response code %d is not declared", resp.StatusCode)}
+	}
+
+	response, err := v.spec.resolveResponse(response)
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+
+	names := make([]string, 0, len(response.Headers))
+	for name := range response.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		// Content-Type is described by the content of the response rather than by its headers
+		if strings.EqualFold(name, "Content-Type") {
+			continue
+		}
+		header, err := v.spec.resolveHeader(response.Headers[name])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		errs = append(errs, v.validateHeader(name, header, resp.Header)...)
+	}
+
+	if len(response.Content) == 0 || len(body) == 0 {
+		return errs
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	media, ok := findContent(response.Content, contentType)
+	if !ok {
+		return append(errs, fmt.Errorf("content type %q is not declared for response code %d", contentType, resp.StatusCode))
+	}
+	if media.Schema == nil || !isJSON(contentType) {
+		return errs
+	}
+
+	schema, err := v.compile(media.Schema)
+	if err != nil {
+		return append(errs, errors.Wrap(err, "invalid response schema"))
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return append(errs, errors.Wrap(err, "response body is not valid json"))
+	}
+
+	for _, violation := range schema.Validate(doc) {
+		errs = append(errs, fmt.Errorf("body %v", violation))
+	}
+
+	return errs
+}
+
+func (v *Validator) validateHeader(name string, header Header, headers http.Header) []error {
+	values, ok := headers[http.CanonicalHeaderKey(name)]
+	if !ok || len(values) == 0 {
+		if header.Required {
+			return []error{fmt.Errorf("required header %s is missing", name)}
+		}
+		return nil
+	}
+
+	if header.Schema == nil {
+		return nil
+	}
+
+	schema, err := v.compile(header.Schema)
+	if err != nil {
+		return []error{errors.Wrapf(err, "invalid schema for header %s", name)}
+	}
+
+	var errs []error
+	for _, violation := range schema.Validate(headerValue(values[0])) {
+		errs = append(errs, fmt.Errorf("header %s %v", name, violation))
+	}
+	return errs
+}
+
+// check resolves the responses of an operation and compiles their schemas
+func (v *Validator) check(op *Operation) error {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		response, err := v.spec.resolveResponse(op.Responses[code])
+		if err != nil {
+			return errors.Wrapf(err, "response %s", code)
+		}
+
+		for name, header := range response.Headers {
+			header, err := v.spec.resolveHeader(header)
+			if err != nil {
+				return errors.Wrapf(err, "response %s: header %s", code, name)
+			}
+			if header.Schema == nil {
+				continue
+			}
+			if _, err := v.compile(header.Schema); err != nil {
+				return errors.Wrapf(err, "response %s: invalid schema for header %s", code, name)
+			}
+		}
+
+		for contentType, media := range response.Content {
+			if media.Schema == nil {
+				continue
+			}
+			if _, err := v.compile(media.Schema); err != nil {
+				return errors.Wrapf(err, "response %s: invalid schema for %s", code, contentType)
+			}
+		}
+	}
+
+	return nil
+}
+
+// compile compiles a schema of the spec once, as responses may be validated concurrently
+func (v *Validator) compile(node interface{}) (*jsonschema.Schema, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// schema nodes are maps decoded once with the spec, so their address identifies them
+	key := fmt.Sprintf("%p", node)
+	if schema, ok := v.schemas[key]; ok {
+		return schema, nil
+	}
+
+	schema, err := v.spec.schema(node)
+	if err != nil {
+		return nil, err
+	}
+	v.schemas[key] = schema
+	return schema, nil
+}
+
+// headerValue converts a header to the JSON value it represents, so that it can be validated against a schema
+func headerValue(value string) interface{} {
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	return value
+}
+
+// findResponse returns the response declared for a status code, either exactly, by range such as 2XX, or by default
+func findResponse(responses map[string]Response, code int) (Response, bool) {
+	if r, ok := responses[strconv.Itoa(code)]; ok {
+		return r, true
+	}
+	if r, ok := responses[fmt.Sprintf("%dXX", code/100)]; ok {
+		return r, true
+	}
+	if r, ok := responses[fmt.Sprintf("%dxx", code/100)]; ok {
+		return r, true
+	}
+	r, ok := responses["default"]
+	return r, ok
+}
+
+// findContent returns the media type declared for a content type, either exactly, such as application/json, by
+// range such as application/*, or */*
+func findContent(content map[string]MediaType, contentType string) (MediaType, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+
+	if m, ok := content[mediaType]; ok {
+		return m, true
+	}
+	if i := strings.Index(mediaType, "/"); i > 0 {
+		if m, ok := content[mediaType[:i]+"/*"]; ok {
+			return m, true
+		}
+	}
+	m, ok := content["*/*"]
+	return m, ok
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func isTemplate(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+func matchSegments(template, segments []string) bool {
+	if len(template) != len(segments) {
+		return false
+	}
+	for i, t := range template {
+		if isTemplate(t) {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if t != segments[i] {
+			return false
+		}
+	}
+	return true
+}
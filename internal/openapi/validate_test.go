@@ -0,0 +1,230 @@
+package openapi
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const validationSpec = `
+openapi: 3.0.1
+info:
+  title: Users
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      responses:
+        "200":
+          description: ok
+          headers:
+            X-Rate-Limit:
+              required: true
+              schema: {type: integer}
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/User"}
+        4XX:
+          $ref: "#/components/responses/Error"
+  /users/me:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            text/plain: {}
+components:
+  responses:
+    Error:
+      description: error
+      content:
+        application/json:
+          schema:
+            type: object
+            required: [message]
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+`
+
+func response(method, path string, code int, headers map[string]string, body string) (*http.Response, []byte) {
+	u, _ := url.Parse("http://localhost" + path)
+	resp := &http.Response{
+		StatusCode: code,
+		Header:     make(http.Header),
+		Request:    &http.Request{Method: method, URL: u},
+	}
+	for k, v := range headers {
+		resp.Header.Set(k, v)
+	}
+	return resp, []byte(body)
+}
+
+var responseValidationTests = []struct {
+	method      string
+	path        string
+	code        int
+	headers     map[string]string
+	body        string
+	expectedErr string
+	description string
+}{
+	{
+		method:      "GET",
+		path:        "/users/1",
+		code:        200,
+		headers:     map[string]string{"Content-Type": "application/json; charset=utf-8", "X-Rate-Limit": "10"},
+		body:        `{"id": 1, "name": "ann"}`,
+		description: "should accept a conforming response",
+	},
+	{
+		method:      "GET",
+		path:        "/api/v1/users/1",
+		code:        200,
+		headers:     map[string]string{"Content-Type": "application/json", "X-Rate-Limit": "10"},
+		body:        `{"id": 1, "name": "ann"}`,
+		description: "should match operations below a base path",
+	},
+	{
+		method:      "GET",
+		path:        "/users/me",
+		code:        200,
+		headers:     map[string]string{"Content-Type": "text/plain"},
+		body:        `ann`,
+		description: "should prefer literal segments over templates",
+	},
+	{
+		method:      "GET",
+		path:        "/users/1",
+		code:        404,
+		headers:     map[string]string{"Content-Type": "application/json"},
+		body:        `{"message": "not found"}`,
+		description: "should match response code ranges",
+	},
+	{
+		method:      "GET",
+		path:        "/users/1",
+		code:        500,
+		expectedErr: "openapi: GET /users/{id}: response code 500 is not declared",
+		description: "should reject undeclared response codes",
+	},
+	{
+		method:      "GET",
+		path:        "/users/1",
+		code:        200,
+		headers:     map[string]string{"Content-Type": "application/json", "X-Rate-Limit": "many"},
+		body:        `{"id": "1"}`,
+		expectedErr: `openapi: GET /users/{id}: header X-Rate-Limit #: expected integer, got string; body #: missing required property "name"; body #/id: expected integer, got string`,
+		description: "should report every header and body violation",
+	},
+	{
+		method:      "GET",
+		path:        "/users/1",
+		code:        200,
+		headers:     map[string]string{"Content-Type": "text/html"},
+		body:        `<html></html>`,
+		expectedErr: `openapi: GET /users/{id}: required header X-Rate-Limit is missing; content type "text/html" is not declared for response code 200`,
+		description: "should reject missing headers and undeclared content types",
+	},
+	{
+		method:      "DELETE",
+		path:        "/users/1",
+		code:        204,
+		expectedErr: "openapi: no operation matches DELETE /users/1",
+		description: "should reject requests without a matching operation",
+	},
+}
+
+func TestValidateResponse(t *testing.T) {
+	spec, err := Parse([]byte(validationSpec))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	validator, err := NewValidator(spec)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	for _, tt := range responseValidationTests {
+		resp, body := response(tt.method, tt.path, tt.code, tt.headers, tt.body)
+		err := validator.ValidateResponse(resp, body)
+
+		if tt.expectedErr == "" {
+			assert.NoError(t, err, tt.description)
+		} else {
+			assert.EqualError(t, err, tt.expectedErr, tt.description)
+		}
+	}
+}
+
+func TestNewValidatorCircularReference(t *testing.T) {
+	tests := []struct {
+		components  string
+		response    string
+		err         string
+		description string
+	}{
+		{
+			components: `
+  schemas:
+    Node: {$ref: "#/components/schemas/Node"}
+`,
+			response: `
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Node"}
+`,
+			err:         `GET /nodes: response 200: invalid schema for application/json: #/components/schemas/Node: circular reference to #/components/schemas/Node`,
+			description: "a schema referencing itself should be reported",
+		},
+		{
+			components: `
+  responses:
+    A: {$ref: "#/components/responses/B"}
+    B: {$ref: "#/components/responses/A"}
+`,
+			response:    `{$ref: "#/components/responses/A"}`,
+			err:         `GET /nodes: response 200: circular reference to #/components/responses/A`,
+			description: "responses referencing each other should be reported",
+		},
+		{
+			components: `
+  headers:
+    H: {$ref: "#/components/headers/H"}
+`,
+			response: `
+          description: ok
+          headers:
+            X-Id: {$ref: "#/components/headers/H"}
+`,
+			err:         `GET /nodes: response 200: header X-Id: circular reference to #/components/headers/H`,
+			description: "a header referencing itself should be reported",
+		},
+	}
+
+	for _, tt := range tests {
+		spec, err := Parse([]byte(`
+openapi: 3.0.1
+info: {title: nodes, version: 1.0.0}
+paths:
+  /nodes:
+    get:
+      responses:
+        "200": ` + tt.response + `
+components:` + tt.components))
+		if !assert.NoError(t, err, tt.description) {
+			continue
+		}
+
+		_, err = NewValidator(spec)
+		assert.EqualError(t, err, tt.err, tt.description)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bluehoodie/smoke/tester"
+)
+
+type lintCommand struct{}
+
+// Execute checks the test files given with --file without running them, and prints the problems found
+func (c *lintCommand) Execute(args []string) error {
+	files, err := tester.FindTestFiles(opts.File)
+	if err != nil {
+		return err
+	}
+
+	problems := 0
+	for _, file := range files {
+		for _, issue := range tester.Lint(file) {
+			fmt.Println(issue)
+			problems++
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("%d problems found", problems)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bluehoodie/smoke/tester"
+)
+
+type loadCommand struct {
+	Users      int           `long:"users" default:"1" description:"number of virtual users running the contracts concurrently, each with its own variables and cookies"`
+	Duration   time.Duration `long:"duration" description:"time to run the load test for, such as 30s or 5m"`
+	Iterations int           `long:"iterations" description:"number of times the contracts are run in total, by all the users"`
+	RPS        float64       `long:"rps" description:"maximum number of requests per second sent by all the users (default: no limit)"`
+}
+
+// Execute runs the contracts of the test files given with --file repeatedly, and reports the latencies, throughput and
+// failures of each contract
+func (c *loadCommand) Execute(args []string) error {
+	if c.Duration <= 0 && c.Iterations <= 0 {
+		return fmt.Errorf("expected --duration or --iterations")
+	}
+
+	tests, err := loadTests()
+	if err != nil {
+		return err
+	}
+
+	filter, err := newFilter()
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient()
+	if err != nil {
+		return err
+	}
+
+	// keep a connection per user, instead of opening new ones for most requests
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	transport.MaxIdleConnsPerHost = c.Users
+	client.Transport = transport
+
+	runners, err := newRunners(tests, client)
+	if err != nil {
+		return err
+	}
+
+	if err := waitForService(runners, tests); err != nil {
+		return err
+	}
+
+	runners, err = filterRunners(filter, runners, tests)
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, runner := range runners {
+		result, err := runner.Load(tester.LoadOptions{
+			Users:      c.Users,
+			Duration:   c.Duration,
+			Iterations: c.Iterations,
+			RPS:        c.RPS,
+		})
+		if result != nil {
+			result.Write(os.Stdout)
+			if result.Failed() > 0 {
+				failed = true
+			}
+		}
+		// a failed setup or teardown
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+
+	return nil
+}
@@ -2,42 +2,223 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
-	"github.com/bluehoodie/smoke/internal/tester"
+	"github.com/bluehoodie/smoke/internal/openapi"
+	"github.com/bluehoodie/smoke/tester"
 
 	"github.com/jessevdk/go-flags"
 )
 
 var opts struct {
-	Verbose bool   `short:"v" long:"verbose" description:"print out full report including successful results"`
-	File    string `short:"f" long:"file" default:"./smoke_test.yaml" description:"file containing the test definition"`
-	URL     string `short:"u" long:"url" default:"https://httpbin.org" description:"url endpoint to test"`
-	Port    int    `short:"p" long:"port" description:"port the service is running on"`
-	Timeout int    `short:"t" long:"timeout" default:"1" description:"timeout in seconds for each http request made"`
+	Verbose       bool     `short:"v" long:"verbose" description:"print out full report including successful results"`
+	File          string   `short:"f" long:"file" default:"./smoke_test.yaml" description:"file containing the test definition, or a directory or glob pattern (** matches any directories) of test files each run as its own suite"`
+	URL           string   `short:"u" long:"url" default:"https://httpbin.org" description:"url endpoint to test, overriding the base_url of the environment"`
+	Port          int      `short:"p" long:"port" description:"port the service is running on"`
+	Timeout       int      `short:"t" long:"timeout" default:"1" description:"timeout in seconds for each http request made"`
+	Parallel      int      `long:"parallel" default:"1" description:"number of contracts to run concurrently, except in test files with a cookie_jar"`
+	Run           string   `long:"run" description:"run only the contracts whose name matches this regular expression"`
+	Tags          string   `long:"tags" description:"run only the contracts with at least one of these comma separated tags"`
+	SkipTags      string   `long:"skip-tags" description:"do not run the contracts with any of these comma separated tags"`
+	FailFast      bool     `long:"fail-fast" description:"stop running the contracts of a test file after the first failure, reporting the others as skipped"`
+	OpenAPI       string   `long:"openapi" description:"OpenAPI 3 spec every response must conform to"`
+	WaitFor       string   `long:"wait-for" description:"wait until the service is ready before running the tests: a path returning a 2xx response, tcp:PORT, tcp:HOST:PORT or contract:NAME"`
+	WaitTimeout   int      `long:"wait-timeout" default:"60" description:"timeout in seconds for the service to be ready"`
+	Env           string   `long:"env" description:"name of the environment of the test file to run against"`
+	CACert        string   `long:"cacert" description:"PEM file of the certificate authorities trusted to verify the service, instead of the system ones"`
+	Cert          string   `long:"cert" description:"PEM file of the client certificate, for mutual TLS"`
+	Key           string   `long:"key" description:"PEM file of the private key of the client certificate"`
+	ServerName    string   `long:"server-name" description:"name the certificate of the service is verified against, instead of the host of the url"`
+	TLSMinVersion string   `long:"tls-min-version" choice:"1.0" choice:"1.1" choice:"1.2" choice:"1.3" description:"minimum TLS version accepted"`
+	Insecure      bool     `long:"insecure" description:"do not verify the certificate of the service"`
+	Reports       []string `long:"report" description:"write a report of the results to a file, in the form format=path. supported formats: junit"`
 }
 
+var flagParser = flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+
 func main() {
-	flagParser := flags.NewParser(&opts, flags.HelpFlag | flags.PassDoubleDash)
+	flagParser.SubcommandsOptional = true
+	flagParser.AddCommand("generate", "generate a test file", "Generate a test file with one contract per operation of an OpenAPI 3 spec.", &generateCommand{})
+	flagParser.AddCommand("lint", "check test files", "Check the test files given with --file without running them: unknown fields, invalid expressions and methods, and variables which are not defined.", &lintCommand{})
+	flagParser.AddCommand("load", "run a load test", "Run the contracts of the test files repeatedly with several virtual users, and report the latency percentiles, throughput and error rate of each contract.", &loadCommand{})
+
 	_, err := flagParser.Parse()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, err.Error())
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// commands are run by the parser, the test suite only runs when no command is given
+	if flagParser.Active != nil {
+		return
+	}
+
+	tests, err := loadTests()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	filter, err := newFilter()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	client, err := newClient()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var runnerOpts []tester.Option
+
+	var reports []*report
+	for _, spec := range opts.Reports {
+		r, err := newReport(spec)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		reports = append(reports, r)
+		runnerOpts = append(runnerOpts, tester.WithReporter(r.reporter))
+	}
+
+	// several suites are summarized once they have all run
+	summary := tester.NewSummaryReporter()
+	if len(tests) > 1 {
+		runnerOpts = append(runnerOpts, tester.WithReporter(summary))
+	}
+
+	runners, err := newRunners(tests, client, runnerOpts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
 	}
 
-	t, err := tester.NewTest(opts.File)
+	if err := waitForService(runners, tests); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	runners, err = filterRunners(filter, runners, tests)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, err.Error())
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
 	}
 
-	url := opts.URL
-	if opts.Port != 0 {
-		url = fmt.Sprintf("%s:%d", url, opts.Port)
+	ok, setupFailed := true, false
+	for _, runner := range runners {
+		if !runner.Run() {
+			ok = false
+		}
+		if runner.SetupFailed() {
+			setupFailed = true
+		}
+	}
+
+	if len(tests) > 1 {
+		successOutput, failureOutput := ioutil.Discard, io.Writer(os.Stderr)
+		if opts.Verbose {
+			successOutput, failureOutput = os.Stdout, os.Stdout
+		}
+		summary.Write(successOutput, failureOutput)
+	}
+
+	for _, r := range reports {
+		if err := r.close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
 	}
 
+	// the tests of a suite whose setup failed could not be run
+	if setupFailed {
+		os.Exit(2)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// loadTests loads the test files given with --file
+func loadTests() ([]*tester.Test, error) {
+	files, err := tester.FindTestFiles(opts.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var tests []*tester.Test
+	for _, file := range files {
+		t, err := tester.NewTest(file)
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, t)
+	}
+
+	return tests, nil
+}
+
+// newFilter returns the filter selecting the contracts to run, given with --run, --tags and --skip-tags
+func newFilter() (tester.Filter, error) {
+	var filter tester.Filter
+	if opts.Run != "" {
+		re, err := regexp.Compile(opts.Run)
+		if err != nil {
+			return filter, fmt.Errorf("invalid --run: %v", err)
+		}
+		filter.Run = re
+	}
+	filter.Tags = splitList(opts.Tags)
+	filter.SkipTags = splitList(opts.SkipTags)
+	return filter, nil
+}
+
+// splitList returns the non empty elements of a comma separated list
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// filterRunners removes the contracts not selected by the filter from every test, and returns the runners of the tests
+// left with contracts to run.  The contracts run only because selected contracts need them are printed.  The filter
+// is applied once the service is ready, as --wait-for may use a contract which is not selected.
+func filterRunners(filter tester.Filter, runners []*tester.Runner, tests []*tester.Test) ([]*tester.Runner, error) {
+	if filter.IsZero() {
+		return runners, nil
+	}
+
+	var selected []*tester.Runner
+	for i, t := range tests {
+		for _, message := range runners[i].Filter(filter) {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", t.Name, message)
+		}
+		if len(t.Contracts) > 0 {
+			selected = append(selected, runners[i])
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no contracts selected by --run, --tags and --skip-tags")
+	}
+	return selected, nil
+}
+
+// newClient returns the http client the tests are run with, configured by the timeout and TLS options
+func newClient() (*http.Client, error) {
+	// redirects are not followed, unless a contract sets follow_redirects
 	client := &http.Client{
 		Timeout: time.Duration(opts.Timeout) * time.Second,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -45,13 +226,142 @@ func main() {
 		},
 	}
 
-	runner := tester.NewRunner(url, t,
+	// the TLS options override the tls settings of the test files and environments
+	tlsOpts := tester.TLS{
+		CACert:     opts.CACert,
+		Cert:       opts.Cert,
+		Key:        opts.Key,
+		ServerName: opts.ServerName,
+		MinVersion: opts.TLSMinVersion,
+		Insecure:   opts.Insecure,
+	}
+	if tlsOpts != (tester.TLS{}) {
+		config, err := tlsOpts.Config(".")
+		if err != nil {
+			return nil, err
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config
+		client.Transport = transport
+	}
+
+	return client, nil
+}
+
+// newRunners returns a runner for every test, configured by the options given on the command line and extra
+func newRunners(tests []*tester.Test, client *http.Client, extra ...tester.Option) ([]*tester.Runner, error) {
+	runnerOpts := []tester.Option{
 		tester.WithVerboseModeOn(opts.Verbose),
 		tester.WithHTTPClient(client),
-	)
+		tester.WithParallelism(opts.Parallel),
+		tester.WithFailFast(opts.FailFast),
+	}
 
-	ok := runner.Run()
-	if !ok {
-		os.Exit(1)
+	if opts.OpenAPI != "" {
+		spec, err := openapi.Load(opts.OpenAPI)
+		if err != nil {
+			return nil, err
+		}
+		validator, err := openapi.NewValidator(spec)
+		if err != nil {
+			return nil, err
+		}
+		runnerOpts = append(runnerOpts, tester.WithResponseValidator(validator))
+	}
+
+	runnerOpts = append(runnerOpts, extra...)
+
+	// the url given on the command line takes precedence over the base_url of the environment
+	urlIsSet := !flagParser.FindOptionByLongName("url").IsSetDefault()
+
+	// every suite gets its own runner, so that the outputs of one suite are not visible to the others
+	var runners []*tester.Runner
+	for _, t := range tests {
+		url := opts.URL
+		testOpts := append([]tester.Option{}, runnerOpts...)
+
+		if opts.Env != "" {
+			env, err := t.Environment(opts.Env)
+			if err != nil {
+				return nil, err
+			}
+			if env.BaseURL != "" && !urlIsSet {
+				url = env.BaseURL
+			}
+			testOpts = append(testOpts, tester.WithEnvironment(env))
+		}
+
+		if opts.Port != 0 {
+			url = fmt.Sprintf("%s:%d", url, opts.Port)
+		}
+
+		runners = append(runners, tester.NewRunner(url, t, testOpts...))
+	}
+
+	return runners, nil
+}
+
+// waitForService waits until the service is ready, when --wait-for is given
+func waitForService(runners []*tester.Runner, tests []*tester.Test) error {
+	if opts.WaitFor == "" {
+		return nil
+	}
+
+	runner := waitRunner(runners, tests, opts.WaitFor)
+	if err := runner.WaitFor(opts.WaitFor, time.Duration(opts.WaitTimeout)*time.Second); err != nil {
+		return fmt.Errorf("service is not ready: %v", err)
+	}
+	return nil
+}
+
+// waitRunner returns the runner to wait for the service with: the runner of the suite defining the contract waited
+// for, if any, or the first one
+func waitRunner(runners []*tester.Runner, tests []*tester.Test, target string) *tester.Runner {
+	if !strings.HasPrefix(target, "contract:") {
+		return runners[0]
+	}
+
+	name := strings.TrimPrefix(target, "contract:")
+	for i, t := range tests {
+		for _, contract := range append(append([]tester.Contract{}, t.Setup...), t.Contracts...) {
+			if contract.Name == name {
+				return runners[i]
+			}
+		}
+	}
+	return runners[0]
+}
+
+// report is a Reporter writing to a file, as requested with the --report option
+type report struct {
+	reporter *tester.JUnitReporter
+	file     *os.File
+}
+
+func newReport(spec string) (*report, error) {
+	parts := strings.SplitN(spec, "=", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid report %q: expected format=path", spec)
+	}
+
+	switch parts[0] {
+	case "junit":
+	default:
+		return nil, fmt.Errorf("invalid report %q: unknown format %v", spec, parts[0])
+	}
+
+	f, err := os.Create(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("could not create report file %v: %v", parts[1], err)
+	}
+
+	return &report{reporter: tester.NewJUnitReporter(f), file: f}, nil
+}
+
+func (r *report) close() error {
+	if err := r.reporter.Flush(); err != nil {
+		r.file.Close()
+		return fmt.Errorf("could not write report %v: %v", r.file.Name(), err)
 	}
+	return r.file.Close()
 }
@@ -0,0 +1,259 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tokenExpiryDelta is how long before its expiry an OAuth2 token is refreshed, so that it does not expire while a
+// request is sent
+var tokenExpiryDelta = 10 * time.Second
+
+// Auth defines how the requests of contracts are authenticated.  Exactly one of its elements must be set.
+type Auth struct {
+	Basic  *BasicAuth  `json:"basic,omitempty" yaml:"basic,omitempty"`
+	Bearer string      `json:"bearer,omitempty" yaml:"bearer,omitempty"`
+	APIKey *APIKeyAuth `json:"api_key,omitempty" yaml:"api_key,omitempty"`
+	OAuth2 *OAuth2Auth `json:"oauth2,omitempty" yaml:"oauth2,omitempty"`
+	// None disables the authentication of the Test for a contract
+	None bool `json:"none,omitempty" yaml:"none,omitempty"`
+}
+
+// BasicAuth sends a username and password in the Authorization header
+type BasicAuth struct {
+	Username string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// APIKeyAuth sends a key in a header or in a query parameter
+type APIKeyAuth struct {
+	// Name is the name of the header or query parameter
+	Name  string `json:"name,omitempty" yaml:"name,omitempty"`
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	// In is either header or query.  Default is header.
+	In string `json:"in,omitempty" yaml:"in,omitempty"`
+}
+
+// OAuth2Auth sends a bearer token obtained from a token endpoint with the client credentials grant
+type OAuth2Auth struct {
+	// TokenURL is the url of the token endpoint, or a path relative to the url of the runner
+	TokenURL     string   `json:"token_url,omitempty" yaml:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty" yaml:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty" yaml:"scopes,omitempty"`
+}
+
+func (a *Auth) init() error {
+	set := 0
+	for _, ok := range []bool{a.Basic != nil, a.Bearer != "", a.APIKey != nil, a.OAuth2 != nil, a.None} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("expected exactly one of basic, bearer, api_key, oauth2 or none")
+	}
+
+	if a.APIKey != nil {
+		if a.APIKey.Name == "" {
+			return fmt.Errorf("api_key: name is required")
+		}
+		if a.APIKey.In != "" && a.APIKey.In != "header" && a.APIKey.In != "query" {
+			return fmt.Errorf("api_key: invalid in %q, expected header or query", a.APIKey.In)
+		}
+	}
+
+	if a.OAuth2 != nil {
+		if a.OAuth2.TokenURL == "" {
+			return fmt.Errorf("oauth2: token_url is required")
+		}
+		if a.OAuth2.ClientID == "" {
+			return fmt.Errorf("oauth2: client_id is required")
+		}
+	}
+
+	return nil
+}
+
+// values returns the values of the auth which may contain variables
+func (a *Auth) values() []*string {
+	var values []*string
+	if a.Basic != nil {
+		values = append(values, &a.Basic.Username, &a.Basic.Password)
+	}
+	values = append(values, &a.Bearer)
+	if a.APIKey != nil {
+		values = append(values, &a.APIKey.Value)
+	}
+	if a.OAuth2 != nil {
+		values = append(values, &a.OAuth2.TokenURL, &a.OAuth2.ClientID, &a.OAuth2.ClientSecret)
+	}
+	return values
+}
+
+// copy returns a deep copy of the auth, whose values can be replaced without changing the Test
+func (a *Auth) copy() *Auth {
+	c := *a
+	if a.Basic != nil {
+		basic := *a.Basic
+		c.Basic = &basic
+	}
+	if a.APIKey != nil {
+		apiKey := *a.APIKey
+		c.APIKey = &apiKey
+	}
+	if a.OAuth2 != nil {
+		oauth2 := *a.OAuth2
+		c.OAuth2 = &oauth2
+	}
+	return &c
+}
+
+// authenticator applies the Auth of contracts to their requests, and caches the OAuth2 tokens it obtains
+type authenticator struct {
+	client *http.Client
+	url    string
+
+	mu     sync.Mutex
+	tokens map[string]*oauth2Token
+}
+
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+
+	// expiry is zero when the token endpoint did not say when the token expires
+	expiry time.Time
+}
+
+func newAuthenticator(client *http.Client, url string) *authenticator {
+	return &authenticator{
+		client: client,
+		url:    url,
+		tokens: make(map[string]*oauth2Token),
+	}
+}
+
+// apply authenticates the request.  The Authorization header, or the header of an API key, is left unchanged when the
+// contract sets it itself.
+func (a *authenticator) apply(req *http.Request, auth *Auth) error {
+	switch {
+	case auth == nil || auth.None:
+		return nil
+
+	case auth.APIKey != nil:
+		if auth.APIKey.In == "query" {
+			param := neturl.QueryEscape(auth.APIKey.Name) + "=" + neturl.QueryEscape(auth.APIKey.Value)
+			if req.URL.RawQuery != "" {
+				param = "&" + param
+			}
+			req.URL.RawQuery += param
+			return nil
+		}
+		if req.Header.Get(auth.APIKey.Name) == "" {
+			req.Header.Set(auth.APIKey.Name, auth.APIKey.Value)
+		}
+		return nil
+	}
+
+	if req.Header.Get("Authorization") != "" {
+		return nil
+	}
+
+	switch {
+	case auth.Basic != nil:
+		req.SetBasicAuth(auth.Basic.Username, auth.Basic.Password)
+
+	case auth.Bearer != "":
+		req.Header.Set("Authorization", "Bearer "+auth.Bearer)
+
+	case auth.OAuth2 != nil:
+		token, err := a.token(auth.OAuth2)
+		if err != nil {
+			return errors.Wrap(err, "could not obtain oauth2 token")
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return nil
+}
+
+// token returns a cached token for the client credentials, or obtains a new one when there is none or it expires soon
+func (a *authenticator) token(config *OAuth2Auth) (string, error) {
+	key := strings.Join([]string{config.TokenURL, config.ClientID, config.ClientSecret, strings.Join(config.Scopes, " ")}, "\n")
+
+	// the lock is held while the token is obtained, so that contracts run in parallel do not all request one
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if token, ok := a.tokens[key]; ok {
+		if token.expiry.IsZero() || time.Now().Add(tokenExpiryDelta).Before(token.expiry) {
+			return token.AccessToken, nil
+		}
+	}
+
+	token, err := a.fetchToken(config)
+	if err != nil {
+		return "", err
+	}
+	a.tokens[key] = token
+
+	return token.AccessToken, nil
+}
+
+func (a *authenticator) fetchToken(config *OAuth2Auth) (*oauth2Token, error) {
+	tokenURL := config.TokenURL
+	if strings.HasPrefix(tokenURL, "/") {
+		tokenURL = a.url + tokenURL
+	}
+
+	form := neturl.Values{"grant_type": {"client_credentials"}}
+	if len(config.Scopes) > 0 {
+		form.Set("scope", strings.Join(config.Scopes, " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(config.ClientID, config.ClientSecret)
+
+	requested := time.Now()
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read token response: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("token endpoint returned http response code %d: %s", resp.StatusCode, body)
+	}
+
+	token := &oauth2Token{}
+	if err := json.Unmarshal(body, token); err != nil {
+		return nil, errors.Wrap(err, "could not parse token response")
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("no access_token in token response")
+	}
+	if token.ExpiresIn > 0 {
+		token.expiry = requested.Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+
+	return token, nil
+}
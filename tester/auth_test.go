@@ -0,0 +1,168 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuth(t *testing.T) {
+	var issued int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			id, secret, _ := r.BasicAuth()
+			if r.Method != http.MethodPost || r.FormValue("grant_type") != "client_credentials" || id != "client" || secret != "s3cret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			n := atomic.AddInt32(&issued, 1)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"access_token": fmt.Sprintf("token-%d-%s", n, r.FormValue("scope")),
+				"token_type":   "Bearer",
+				"expires_in":   3600,
+			})
+			return
+		}
+		fmt.Fprintf(w, "%s|%s|%s", r.Header.Get("Authorization"), r.Header.Get("X-Api-Key"), r.URL.RawQuery)
+	}))
+	defer server.Close()
+
+	oauth2 := &Auth{OAuth2: &OAuth2Auth{TokenURL: "/token", ClientID: "client", ClientSecret: "::secret::", Scopes: []string{"read", "write"}}}
+
+	tests := []struct {
+		description string
+		auth        *Auth
+		path        string
+		headers     map[string]string
+		expected    string
+	}{
+		{
+			description: "basic",
+			auth:        &Auth{Basic: &BasicAuth{Username: "ann", Password: "pass"}},
+			expected:    "Basic YW5uOnBhc3M=||",
+		},
+		{
+			description: "bearer with variable",
+			auth:        &Auth{Bearer: "::token::"},
+			expected:    "Bearer abc||",
+		},
+		{
+			description: "api key in header",
+			auth:        &Auth{APIKey: &APIKeyAuth{Name: "X-Api-Key", Value: "key"}},
+			expected:    "|key|",
+		},
+		{
+			description: "api key in query",
+			auth:        &Auth{APIKey: &APIKeyAuth{Name: "api key", Value: "a&b", In: "query"}},
+			path:        "?id=1",
+			expected:    "||id=1&api+key=a%26b",
+		},
+		{
+			description: "oauth2 client credentials",
+			auth:        oauth2,
+			expected:    "Bearer token-1-read write||",
+		},
+		{
+			description: "oauth2 token is cached",
+			auth:        oauth2,
+			expected:    "Bearer token-1-read write||",
+		},
+		{
+			description: "authorization header of the contract",
+			auth:        &Auth{Bearer: "abc"},
+			headers:     map[string]string{"Authorization": "Custom"},
+			expected:    "Custom||",
+		},
+		{
+			description: "none",
+			auth:        &Auth{None: true},
+			expected:    "||",
+		},
+	}
+
+	runner := NewRunner(server.URL, &Test{Globals: map[string]string{"token": "abc", "secret": "s3cret"}})
+
+	for _, test := range tests {
+		contract := Contract{Name: test.description, Path: "/" + test.path, Method: "GET", Headers: test.headers, Auth: test.auth, ExpectedResponses: []string{test.expected}}
+		result := &ContractResult{}
+		assert.NoError(t, runner.validateContract(contract, result), test.description)
+		if assert.NotNil(t, result.Response, test.description) {
+			assert.Equal(t, test.expected, string(result.Response.Body), test.description)
+		}
+	}
+
+	// the auth of the Test is not changed by the replacement of variables
+	assert.Equal(t, "::secret::", oauth2.OAuth2.ClientSecret)
+}
+
+func TestOAuth2TokenRefresh(t *testing.T) {
+	var issued int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&issued, 1)
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_in": 60}`, n)
+	}))
+	defer server.Close()
+
+	a := newAuthenticator(http.DefaultClient, server.URL)
+	config := &OAuth2Auth{TokenURL: "/token", ClientID: "client"}
+
+	token, err := a.token(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	token, err = a.token(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// a token about to expire is refreshed
+	for _, cached := range a.tokens {
+		cached.expiry = time.Now().Add(tokenExpiryDelta / 2)
+	}
+
+	token, err = a.token(config)
+	assert.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+}
+
+func TestOAuth2TokenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": "invalid_client"}`)
+	}))
+	defer server.Close()
+
+	contract := Contract{Name: "c", Path: "/", Method: "GET", Auth: &Auth{OAuth2: &OAuth2Auth{TokenURL: "/token", ClientID: "client"}}}
+	err := NewRunner(server.URL, &Test{}).validateContract(contract, &ContractResult{})
+
+	assert.EqualError(t, err, `could not authenticate request: could not obtain oauth2 token: token endpoint returned http response code 401: {"error": "invalid_client"}`)
+}
+
+func TestAuthInit(t *testing.T) {
+	tests := []struct {
+		description string
+		auth        Auth
+		valid       bool
+	}{
+		{description: "bearer", auth: Auth{Bearer: "abc"}, valid: true},
+		{description: "empty", auth: Auth{}},
+		{description: "several", auth: Auth{Bearer: "abc", Basic: &BasicAuth{Username: "ann"}}},
+		{description: "api key without name", auth: Auth{APIKey: &APIKeyAuth{Value: "key"}}},
+		{description: "api key in body", auth: Auth{APIKey: &APIKeyAuth{Name: "key", In: "body"}}},
+		{description: "oauth2 without token url", auth: Auth{OAuth2: &OAuth2Auth{ClientID: "client"}}},
+	}
+
+	for _, test := range tests {
+		err := test.auth.init()
+		if test.valid {
+			assert.NoError(t, err, test.description)
+		} else {
+			assert.Error(t, err, test.description)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package tester
+
+import (
+	"crypto/tls"
+	"crypto/x509/pkix"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CertificateAssertion holds the expectations on the certificate presented by the service
+type CertificateAssertion struct {
+	// Subject is the expected common name of the subject, or its full distinguished name such as
+	// "CN=api.example.com,O=Example".  Can be a regular expression beginning by "r/", matched against the full
+	// distinguished name.
+	Subject string `json:"subject,omitempty" yaml:"subject,omitempty"`
+	// Issuer is the expected issuer, in the same form as Subject
+	Issuer string `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	// SANs are names which must all be among the DNS names, IP addresses, email addresses and URIs of the certificate
+	SANs []string `json:"sans,omitempty" yaml:"sans,omitempty"`
+	// MinDaysUntilExpiry is the minimum number of days the certificate must remain valid for
+	MinDaysUntilExpiry int `json:"min_days_until_expiry,omitempty" yaml:"min_days_until_expiry,omitempty"`
+
+	subject pattern
+	issuer  pattern
+}
+
+func (c *CertificateAssertion) init() error {
+	var err error
+	if c.subject, err = compilePattern(c.Subject); err != nil {
+		return errors.Wrap(err, "subject")
+	}
+	if c.issuer, err = compilePattern(c.Issuer); err != nil {
+		return errors.Wrap(err, "issuer")
+	}
+	if c.MinDaysUntilExpiry < 0 {
+		return fmt.Errorf("min_days_until_expiry must be positive, got %d", c.MinDaysUntilExpiry)
+	}
+	return nil
+}
+
+// validate returns an error describing every expectation the certificate of the connection does not meet
+func (c *CertificateAssertion) validate(state *tls.ConnectionState) error {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("expected a peer certificate, but the connection does not use TLS")
+	}
+	cert := state.PeerCertificates[0]
+
+	var failures []string
+
+	if c.Subject != "" && !matchName(c.subject, cert.Subject) {
+		failures = append(failures, fmt.Sprintf("expected subject %q, got %q", c.Subject, cert.Subject.String()))
+	}
+	if c.Issuer != "" && !matchName(c.issuer, cert.Issuer) {
+		failures = append(failures, fmt.Sprintf("expected issuer %q, got %q", c.Issuer, cert.Issuer.String()))
+	}
+
+	if len(c.SANs) > 0 {
+		names := append([]string{}, cert.DNSNames...)
+		for _, ip := range cert.IPAddresses {
+			names = append(names, ip.String())
+		}
+		names = append(names, cert.EmailAddresses...)
+		for _, uri := range cert.URIs {
+			names = append(names, uri.String())
+		}
+
+		for _, san := range c.SANs {
+			if !containsString(names, san) {
+				failures = append(failures, fmt.Sprintf("expected subject alternative name %q, got [%s]", san, strings.Join(names, ", ")))
+			}
+		}
+	}
+
+	if c.MinDaysUntilExpiry > 0 {
+		days := int(time.Until(cert.NotAfter).Hours() / 24)
+		if days < c.MinDaysUntilExpiry {
+			failures = append(failures, fmt.Sprintf("expected to expire in at least %d days, expires in %d days on %s", c.MinDaysUntilExpiry, days, cert.NotAfter.Format("2006-01-02")))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("peer certificate: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// matchName returns whether the common name or the distinguished name matches a literal name, or whether the
+// distinguished name matches a regular expression
+func matchName(expected pattern, name pkix.Name) bool {
+	if expected.re != nil {
+		return expected.matchString(name.String())
+	}
+	return expected.matchString(name.CommonName) || expected.matchString(name.String())
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package tester
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		description string
+		assertion   CertificateAssertion
+		expected    string
+	}{
+		{
+			description: "matching certificate",
+			assertion:   CertificateAssertion{Subject: "O=Acme Co", SANs: []string{"example.com", "127.0.0.1"}, MinDaysUntilExpiry: 30},
+		},
+		{
+			description: "subject regular expression",
+			assertion:   CertificateAssertion{Subject: "r/^O=Acme"},
+		},
+		{
+			description: "wrong subject and missing name",
+			assertion:   CertificateAssertion{Subject: "api.example.com", SANs: []string{"api.example.com"}},
+			expected:    `r/^peer certificate: expected subject "api.example.com", got "O=Acme Co"; expected subject alternative name "api.example.com", got \[example.com, .*127.0.0.1, ::1\]$`,
+		},
+		{
+			description: "expiring certificate",
+			assertion:   CertificateAssertion{MinDaysUntilExpiry: 1000000},
+			expected:    `r/^peer certificate: expected to expire in at least 1000000 days, expires in \d+ days on \d{4}-\d{2}-\d{2}$`,
+		},
+	}
+
+	runner := NewRunner(server.URL, &Test{}, WithHTTPClient(server.Client()))
+
+	for _, test := range tests {
+		assertion := test.assertion
+		if !assert.NoError(t, assertion.init(), test.description) {
+			continue
+		}
+
+		contract := Contract{Name: test.description, Path: "/", Method: "GET", PeerCertificate: &assertion}
+		err := runner.validateContract(contract, &ContractResult{})
+
+		switch {
+		case test.expected == "":
+			assert.NoError(t, err, test.description)
+		case test.expected[:2] == "r/":
+			if assert.Error(t, err, test.description) {
+				assert.Regexp(t, test.expected[2:], err.Error(), test.description)
+			}
+		default:
+			assert.EqualError(t, err, test.expected, test.description)
+		}
+	}
+}
+
+func TestPeerCertificateWithoutTLS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	contract := Contract{Name: "plain", Path: "/", Method: "GET", PeerCertificate: &CertificateAssertion{Subject: "example.com"}}
+	err := NewRunner(server.URL, &Test{}).validateContract(contract, &ContractResult{})
+
+	assert.EqualError(t, err, "expected a peer certificate, but the connection does not use TLS")
+}
@@ -0,0 +1,208 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sessionJar is a cookie jar which can be emptied while it is in use
+type sessionJar struct {
+	mu  sync.Mutex
+	jar *cookiejar.Jar
+}
+
+func newSessionJar() *sessionJar {
+	j := &sessionJar{}
+	j.reset()
+	return j
+}
+
+// SetCookies implements http.CookieJar
+func (j *sessionJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+}
+
+// Cookies implements http.CookieJar
+func (j *sessionJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// reset removes every cookie from the jar
+func (j *sessionJar) reset() {
+	// cookiejar.New never fails without options
+	jar, _ := cookiejar.New(nil)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar = jar
+}
+
+// applyCookieJar gives the client of the runner a cookie jar of its own, when the Test asks for one
+func (runner *Runner) applyCookieJar() {
+	if !runner.test.CookieJar {
+		return
+	}
+
+	runner.jar = newSessionJar()
+
+	client := *runner.client
+	client.Jar = runner.jar
+	runner.client = &client
+}
+
+// CookieAssertion holds the expectations on a cookie set by a response.  Unset fields are not checked.
+type CookieAssertion struct {
+	// Value is the expected value, or a regular expression beginning by "r/"
+	Value    string `json:"value,omitempty" yaml:"value,omitempty"`
+	Secure   *bool  `json:"secure,omitempty" yaml:"secure,omitempty"`
+	HTTPOnly *bool  `json:"http_only,omitempty" yaml:"http_only,omitempty"`
+	// SameSite is one of strict, lax or none
+	SameSite string `json:"same_site,omitempty" yaml:"same_site,omitempty"`
+	Path     string `json:"path,omitempty" yaml:"path,omitempty"`
+	Domain   string `json:"domain,omitempty" yaml:"domain,omitempty"`
+	// MinLifetime and MaxLifetime bound the time until the cookie expires.  A cookie which is deleted has a negative
+	// lifetime, and a session cookie has none.
+	MinLifetime *Duration `json:"min_lifetime,omitempty" yaml:"min_lifetime,omitempty"`
+	MaxLifetime *Duration `json:"max_lifetime,omitempty" yaml:"max_lifetime,omitempty"`
+
+	value pattern
+}
+
+func (c *CookieAssertion) init() error {
+	if c.SameSite != "" && c.SameSite != "strict" && c.SameSite != "lax" && c.SameSite != "none" {
+		return fmt.Errorf("invalid same_site %q, expected strict, lax or none", c.SameSite)
+	}
+	value, err := compilePattern(c.Value)
+	if err != nil {
+		return errors.Wrap(err, "value")
+	}
+	c.value = value
+	return nil
+}
+
+func sameSiteName(s http.SameSite) string {
+	switch s {
+	case http.SameSiteStrictMode:
+		return "strict"
+	case http.SameSiteLaxMode:
+		return "lax"
+	case http.SameSiteNoneMode:
+		return "none"
+	}
+	return "not set"
+}
+
+// cookieLifetime returns the time until the cookie expires, and false for a session cookie
+func cookieLifetime(cookie *http.Cookie) (time.Duration, bool) {
+	switch {
+	case cookie.MaxAge > 0:
+		return time.Duration(cookie.MaxAge) * time.Second, true
+	case cookie.MaxAge < 0:
+		return -time.Second, true
+	case !cookie.Expires.IsZero():
+		return time.Until(cookie.Expires), true
+	}
+	return 0, false
+}
+
+// match returns a description of every expectation the cookie does not meet
+func (c *CookieAssertion) match(cookie *http.Cookie) []string {
+	var failures []string
+	fail := func(format string, args ...interface{}) {
+		failures = append(failures, fmt.Sprintf("cookie %s: ", cookie.Name)+fmt.Sprintf(format, args...))
+	}
+
+	switch {
+	case c.value.re != nil:
+		if !c.value.matchString(cookie.Value) {
+			fail("regular expression did not find any matches in value %s", cookie.Value)
+		}
+	case !c.value.isZero() && !c.value.matchString(cookie.Value):
+		fail("expected value %s got %s", c.value.literal, cookie.Value)
+	}
+
+	if c.Secure != nil && *c.Secure != cookie.Secure {
+		fail("expected secure %v got %v", *c.Secure, cookie.Secure)
+	}
+	if c.HTTPOnly != nil && *c.HTTPOnly != cookie.HttpOnly {
+		fail("expected http_only %v got %v", *c.HTTPOnly, cookie.HttpOnly)
+	}
+	if c.SameSite != "" && c.SameSite != sameSiteName(cookie.SameSite) {
+		fail("expected same_site %s got %s", c.SameSite, sameSiteName(cookie.SameSite))
+	}
+	if c.Path != "" && c.Path != cookie.Path {
+		fail("expected path %s got %s", c.Path, cookie.Path)
+	}
+	if c.Domain != "" && strings.TrimPrefix(c.Domain, ".") != strings.TrimPrefix(cookie.Domain, ".") {
+		fail("expected domain %s got %s", c.Domain, cookie.Domain)
+	}
+
+	if c.MinLifetime != nil || c.MaxLifetime != nil {
+		lifetime, ok := cookieLifetime(cookie)
+		switch {
+		case !ok:
+			fail("expected an expiry, got a session cookie")
+		case c.MinLifetime != nil && lifetime < time.Duration(*c.MinLifetime):
+			fail("expected to expire in at least %v, expires in %v", *c.MinLifetime, lifetime.Round(time.Second))
+		case c.MaxLifetime != nil && lifetime > time.Duration(*c.MaxLifetime):
+			fail("expected to expire in at most %v, expires in %v", *c.MaxLifetime, lifetime.Round(time.Second))
+		}
+	}
+
+	return failures
+}
+
+func validateCookies(contract Contract, resp *http.Response) error {
+	cookies := make(map[string]*http.Cookie)
+	for _, cookie := range resp.Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	names := make([]string, 0, len(contract.ExpectedCookies))
+	for name := range contract.ExpectedCookies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failures []string
+	for _, name := range names {
+		cookie, ok := cookies[name]
+		if !ok {
+			failures = append(failures, fmt.Sprintf("expected cookie %s not set by the response", name))
+			continue
+		}
+		if assertion := contract.ExpectedCookies[name]; assertion != nil {
+			failures = append(failures, assertion.match(cookie)...)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// responseCookie returns the value of a cookie set by the response
+func responseCookie(resp *http.Response, name string) (string, error) {
+	if resp != nil {
+		for _, cookie := range resp.Cookies() {
+			if cookie.Name == name {
+				return cookie.Value, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cookie %s not set by the response", name)
+}
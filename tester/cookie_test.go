@@ -0,0 +1,124 @@
+package tester
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sessionServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc123", Path: "/", MaxAge: 3600, HttpOnly: true, SameSite: http.SameSiteLaxMode})
+		case "/logout":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "", Path: "/", MaxAge: -1})
+		default:
+			if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "abc123" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+}
+
+func TestCookieJar(t *testing.T) {
+	server := sessionServer()
+	defer server.Close()
+
+	yes, hour, zero := true, Duration(time.Hour), Duration(0)
+	test := &Test{
+		CookieJar: true,
+		Contracts: []Contract{
+			{Name: "anonymous", Path: "/me", Method: "GET", ExpectedHTTPCode: 401},
+			{
+				Name: "login", Path: "/login", Method: "POST", ExpectedHTTPCode: 200,
+				ExpectedCookies: map[string]*CookieAssertion{
+					"session": {Value: "r/^[a-z0-9]+$", HTTPOnly: &yes, SameSite: "lax", Path: "/", MaxLifetime: &hour},
+				},
+				Outputs: map[string]string{"session_id": "cookie.session"},
+			},
+			{Name: "session", Path: "/me", Method: "GET", ExpectedHTTPCode: 200},
+			{Name: "reset", Path: "/me", Method: "GET", ResetCookies: true, ExpectedHTTPCode: 401},
+			{Name: "logout", Path: "/logout", Method: "POST", ExpectedCookies: map[string]*CookieAssertion{"session": {MaxLifetime: &zero}}},
+		},
+	}
+	if !assert.NoError(t, test.init()) {
+		return
+	}
+
+	reporter := &recordingReporter{}
+	runner := NewRunner(server.URL, test, WithReporter(reporter), WithParallelism(4))
+
+	assert.True(t, runner.Run())
+	for _, result := range reporter.results {
+		assert.NoError(t, result.Err, result.Name)
+	}
+
+	value, _ := runner.globals.get("session_id")
+	assert.Equal(t, "abc123", value)
+
+	// the cookies of a runner are not shared with other runners
+	other := &Test{CookieJar: true, Contracts: []Contract{{Name: "session", Path: "/me", Method: "GET", ExpectedHTTPCode: 401}}}
+	assert.True(t, NewRunner(server.URL, other).Run())
+}
+
+func TestCookieAssertions(t *testing.T) {
+	server := sessionServer()
+	defer server.Close()
+
+	yes, minute, day := true, Duration(time.Minute), Duration(24*time.Hour)
+
+	tests := []struct {
+		description string
+		path        string
+		cookies     map[string]*CookieAssertion
+		expected    string
+	}{
+		{
+			description: "cookie set",
+			path:        "/login",
+			cookies:     map[string]*CookieAssertion{"session": nil},
+		},
+		{
+			description: "cookie not set",
+			path:        "/me",
+			cookies:     map[string]*CookieAssertion{"session": nil},
+			expected:    "expected cookie session not set by the response",
+		},
+		{
+			description: "attributes",
+			path:        "/login",
+			cookies: map[string]*CookieAssertion{
+				"session": {Value: "xyz", Secure: &yes, SameSite: "strict", MinLifetime: &day},
+			},
+			expected: "cookie session: expected value xyz got abc123; cookie session: expected secure true got false; " +
+				"cookie session: expected same_site strict got lax; cookie session: expected to expire in at least 24h0m0s, expires in 1h0m0s",
+		},
+		{
+			description: "deleted cookie",
+			path:        "/logout",
+			cookies:     map[string]*CookieAssertion{"session": {MinLifetime: &minute}},
+			expected:    "cookie session: expected to expire in at least 1m0s, expires in -1s",
+		},
+	}
+
+	for _, test := range tests {
+		contract := Contract{Name: test.description, Path: test.path, Method: "GET", ExpectedCookies: test.cookies}
+		runner := NewRunner(server.URL, &Test{Contracts: []Contract{contract}})
+		err := runner.validateContract(runner.test.Contracts[0], &ContractResult{})
+
+		if test.expected == "" {
+			assert.NoError(t, err, test.description)
+		} else {
+			assert.EqualError(t, err, test.expected, test.description)
+		}
+	}
+}
+
+func TestResetCookiesRequiresJar(t *testing.T) {
+	test := &Test{Contracts: []Contract{{Name: "reset", ResetCookies: true}}}
+	assert.EqualError(t, test.init(), "contract reset: reset_cookies requires cookie_jar")
+}
@@ -0,0 +1,205 @@
+package tester
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// testFileExtensions are the extensions of the files run when a directory is given to FindTestFiles
+var testFileExtensions = map[string]bool{
+	".json": true,
+	".yaml": true,
+	".yml":  true,
+}
+
+// FindTestFiles returns the test files designated by name, sorted:
+//   - a glob pattern returns every file it matches.  ** matches any number of directories.
+//   - a directory returns every JSON and YAML file it contains, including in its subdirectories
+//   - any other name is returned as is
+//
+// The files a glob pattern or a directory gives which are included by another one of them, or are the schema file of
+// one of their contracts, are left out.
+func FindTestFiles(name string) ([]string, error) {
+	if isPattern(name) {
+		matches, err := glob(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var files []string
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				files = append(files, match)
+			}
+		}
+		files = withoutReferencedFiles(files)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no test file matches %v", name)
+		}
+		return files, nil
+	}
+
+	info, err := os.Stat(name)
+	if err != nil || !info.IsDir() {
+		return []string{name}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(name, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && testFileExtensions[strings.ToLower(filepath.Ext(file))] {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read test directory %v", name)
+	}
+	files = withoutReferencedFiles(files)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no test file found in %v", name)
+	}
+
+	return files, nil
+}
+
+// withoutReferencedFiles returns the files which are not included by another one of the files, nor the schema file of
+// one of their contracts
+func withoutReferencedFiles(files []string) []string {
+	referenced := make(map[string]bool)
+	for _, file := range files {
+		for _, ref := range referencedFiles(file) {
+			referenced[ref] = true
+		}
+	}
+
+	var tests []string
+	for _, file := range files {
+		if abs, err := filepath.Abs(file); err != nil || !referenced[abs] {
+			tests = append(tests, file)
+		}
+	}
+	return tests
+}
+
+// referencedFiles returns the absolute paths of the files a test file includes or reads the schemas of its contracts
+// and templates from.  Nothing is returned for a file which cannot be read, its errors are reported when it is run.
+func referencedFiles(file string) []string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	t := &Test{}
+	if err := unmarshalInputFile(file, data, t); err != nil {
+		return nil
+	}
+	dir := filepath.Dir(file)
+
+	var refs []string
+	for _, pattern := range t.Include {
+		included, _ := includedFiles(dir, pattern)
+		refs = append(refs, included...)
+	}
+
+	var contracts []Contract
+	for _, phase := range t.phases() {
+		contracts = append(contracts, phase.contracts...)
+	}
+	for _, template := range t.Templates {
+		contracts = append(contracts, template)
+	}
+	for _, contract := range contracts {
+		if schema, ok := contract.ResponseSchema.(string); ok {
+			if !filepath.IsAbs(schema) {
+				schema = filepath.Join(dir, schema)
+			}
+			refs = append(refs, schema)
+		}
+	}
+
+	for i, ref := range refs {
+		if abs, err := filepath.Abs(ref); err == nil {
+			refs[i] = abs
+		}
+	}
+	return refs
+}
+
+func isPattern(name string) bool {
+	return strings.ContainsAny(name, "*?[")
+}
+
+// glob works like filepath.Glob, with the addition of ** which matches zero or more directories
+func glob(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern %v", pattern)
+	}
+
+	segments := strings.Split(pattern, "/")
+
+	// walk from the longest directory without wildcards
+	i := 0
+	for i < len(segments) && !isPattern(segments[i]) {
+		i++
+	}
+	root := strings.Join(segments[:i], "/")
+	if root == "" {
+		root = "."
+		if strings.HasPrefix(pattern, "/") {
+			root = "/"
+		}
+	}
+
+	var matches []string
+	err := filepath.Walk(filepath.FromSlash(root), func(file string, info os.FileInfo, err error) error {
+		// unreadable files and directories are skipped, as with filepath.Glob
+		if err != nil {
+			return nil
+		}
+		if matchSegments(segments, strings.Split(filepath.ToSlash(file), "/")) {
+			matches = append(matches, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// matchSegments reports whether the segments of a path match the segments of a pattern
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	ok, _ := path.Match(pattern[0], segments[0])
+	return ok && matchSegments(pattern[1:], segments[1:])
+}
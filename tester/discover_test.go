@@ -0,0 +1,120 @@
+package tester
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindTestFiles(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"a.smoke.yaml":               "",
+		"notes.txt":                  "",
+		"users/b.smoke.yaml":         "",
+		"users/c.json":               "",
+		"users/admin/d.smoke.yaml":   "",
+		"users/admin/e.smoke.yml":    "",
+		"orders/nested/f.smoke.yaml": "",
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		description string
+		name        string
+		expected    []string
+		err         bool
+	}{
+		{
+			description: "file",
+			name:        "a.smoke.yaml",
+			expected:    []string{"a.smoke.yaml"},
+		},
+		{
+			description: "directory",
+			name:        "users",
+			expected:    []string{"users/admin/d.smoke.yaml", "users/admin/e.smoke.yml", "users/b.smoke.yaml", "users/c.json"},
+		},
+		{
+			description: "glob",
+			name:        "users/*.yaml",
+			expected:    []string{"users/b.smoke.yaml"},
+		},
+		{
+			description: "recursive glob",
+			name:        "**/*.smoke.yaml",
+			expected:    []string{"a.smoke.yaml", "orders/nested/f.smoke.yaml", "users/admin/d.smoke.yaml", "users/b.smoke.yaml"},
+		},
+		{
+			description: "recursive glob in a directory",
+			name:        "users/**/*.smoke.y*ml",
+			expected:    []string{"users/admin/d.smoke.yaml", "users/admin/e.smoke.yml", "users/b.smoke.yaml"},
+		},
+		{
+			description: "recursive glob between directories",
+			name:        "orders/**/f.smoke.yaml",
+			expected:    []string{"orders/nested/f.smoke.yaml"},
+		},
+		{
+			description: "glob without match",
+			name:        "**/*.toml",
+			err:         true,
+		},
+	}
+
+	for _, test := range tests {
+		files, err := FindTestFiles(filepath.Join(dir, test.name))
+		if test.err {
+			assert.Error(t, err, test.description)
+			continue
+		}
+		if !assert.NoError(t, err, test.description) {
+			continue
+		}
+
+		var relative []string
+		for _, file := range files {
+			rel, _ := filepath.Rel(dir, file)
+			relative = append(relative, filepath.ToSlash(rel))
+		}
+		assert.Equal(t, test.expected, relative, test.description)
+	}
+}
+
+func TestFindTestFilesSkipsIncludedAndSchemaFiles(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"main.yaml": `
+include:
+  - parts/*.yaml
+templates:
+  base:
+    method: GET
+contracts:
+  - name: health
+    path: /health
+    response_schema: schemas/health.json
+`,
+		"parts/users.yaml": `
+contracts:
+  - name: users
+    path: /users
+    extends: base
+`,
+		"schemas/health.json": `{"type": "object"}`,
+	})
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{dir, filepath.Join(dir, "**", "*.yaml"), filepath.Join(dir, "**", "*")} {
+		files, err := FindTestFiles(name)
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, []string{filepath.Join(dir, "main.yaml")}, files, name)
+		}
+	}
+
+	// the included file runs with the templates of the file including it
+	test, err := loadTest(filepath.Join(dir, "main.yaml"), make(map[string]bool))
+	if assert.NoError(t, err) {
+		assert.NoError(t, test.init())
+	}
+}
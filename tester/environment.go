@@ -0,0 +1,101 @@
+package tester
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Environment holds the settings of one of the deployments a Test can be run against
+type Environment struct {
+	// BaseURL is the url of the service in this environment, to be given to NewRunner
+	BaseURL string `json:"base_url,omitempty" yaml:"base_url,omitempty"`
+	// Globals override the globals of the Test
+	Globals map[string]string `json:"globals,omitempty" yaml:"globals,omitempty"`
+	// Headers are added to the request of every contract which does not set them
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+	TLS     *TLS              `json:"tls,omitempty" yaml:"tls,omitempty"`
+
+	// dir is the directory of the test file defining the environment, against which the TLS files are resolved
+	dir       string
+	tlsConfig *tls.Config
+}
+
+// Environment returns the environment of the Test with the given name
+func (t *Test) Environment(name string) (*Environment, error) {
+	env, ok := t.Environments[name]
+	if !ok {
+		names := make([]string, 0, len(t.Environments))
+		for n := range t.Environments {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("environment %v not found in %v: no environments defined", name, t.Name)
+		}
+		return nil, fmt.Errorf("environment %v not found in %v: expected one of %v", name, t.Name, strings.Join(names, ", "))
+	}
+	return env, nil
+}
+
+func (e *Environment) init(defaultDir string) error {
+	if e.TLS == nil {
+		return nil
+	}
+
+	dir := e.dir
+	if dir == "" {
+		dir = defaultDir
+	}
+
+	config, err := e.TLS.Config(dir)
+	if err != nil {
+		return errors.Wrap(err, "tls")
+	}
+	e.tlsConfig = config
+
+	return nil
+}
+
+// WithEnvironment returns an Option which runs the Test in an environment: its globals override the globals of the
+// Test, its headers are sent with every request and its TLS settings are used to connect to the service.
+func WithEnvironment(env *Environment) Option {
+	return func(r *Runner) {
+		r.environment = env
+	}
+}
+
+// applyEnvironment adds the globals of the environment of the runner
+func (runner *Runner) applyEnvironment() {
+	env := runner.environment
+	if env == nil {
+		return
+	}
+
+	for key, value := range env.Globals {
+		runner.globals.set(key, value)
+	}
+}
+
+// withEnvironmentHeaders returns the contract with the headers of the environment it does not set itself
+func (runner *Runner) withEnvironmentHeaders(contract Contract) Contract {
+	if runner.environment == nil || len(runner.environment.Headers) == 0 {
+		return contract
+	}
+
+	headers := make(map[string]string, len(contract.Headers)+len(runner.environment.Headers))
+	for key, value := range runner.environment.Headers {
+		headers[http.CanonicalHeaderKey(key)] = value
+	}
+	for key, value := range contract.Headers {
+		delete(headers, http.CanonicalHeaderKey(key))
+		headers[key] = value
+	}
+	contract.Headers = headers
+
+	return contract
+}
@@ -0,0 +1,141 @@
+package tester
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironment(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"main.yaml": `
+name: main
+include: [environments.yaml]
+environments:
+  dev:
+    base_url: http://localhost:8000
+`,
+		"environments.yaml": `
+environments:
+  dev:
+    base_url: http://ignored
+  staging:
+    base_url: https://staging.example.com
+    globals:
+      user: staging
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	test, err := NewTest(filepath.Join(dir, "main.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	dev, err := test.Environment("dev")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "http://localhost:8000", dev.BaseURL)
+	}
+
+	staging, err := test.Environment("staging")
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"user": "staging"}, staging.Globals)
+	}
+
+	_, err = test.Environment("prod")
+	assert.EqualError(t, err, "environment prod not found in main: expected one of dev, staging")
+}
+
+func TestRunnerEnvironment(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s", r.URL.Query().Get("user"), r.Header.Get("X-Env"), r.Header.Get("Accept"))
+	}))
+	defer server.Close()
+
+	test := &Test{
+		Globals: map[string]string{"user": "global", "env": "global"},
+		Contracts: []Contract{
+			{Name: "default_headers", Path: "/?user=::user::", Method: "GET", ExpectedResponses: []string{"env staging text/plain"}},
+			{Name: "contract_headers", Path: "/?user=::user::", Method: "GET", Headers: map[string]string{"accept": "application/json"}, ExpectedResponses: []string{"env staging application/json"}},
+		},
+	}
+	env := &Environment{
+		Globals: map[string]string{"user": "env", "env": "staging"},
+		Headers: map[string]string{"X-Env": "::env::", "Accept": "text/plain"},
+	}
+
+	reporter := &recordingReporter{}
+	ok := NewRunner(server.URL, test, WithEnvironment(env), WithReporter(reporter)).Run()
+
+	assert.True(t, ok)
+	for _, result := range reporter.results {
+		assert.NoError(t, result.Err, result.Name)
+	}
+}
+
+func TestEnvironmentTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "smoke")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ca.pem"), cert, 0644))
+
+	tests := []struct {
+		description string
+		tls         *TLS
+		expected    bool
+	}{
+		{
+			description: "certificate of the service not trusted",
+			expected:    false,
+		},
+		{
+			description: "certificate authority of the environment",
+			tls:         &TLS{CACert: "ca.pem"},
+			expected:    true,
+		},
+		{
+			description: "verification disabled",
+			tls:         &TLS{Insecure: true},
+			expected:    true,
+		},
+	}
+
+	for _, test := range tests {
+		env := &Environment{TLS: test.tls}
+		assert.NoError(t, env.init(dir), test.description)
+
+		suite := &Test{Contracts: []Contract{{Name: "tls", Path: "/", Method: "GET", ExpectedHTTPCode: 200}}}
+		ok := NewRunner(server.URL, suite, WithEnvironment(env), WithReporter(&recordingReporter{})).Run()
+		assert.Equal(t, test.expected, ok, test.description)
+	}
+}
+
+func TestTLSConfigErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		tls         TLS
+	}{
+		{description: "missing ca_cert", tls: TLS{CACert: "missing.pem"}},
+		{description: "cert without key", tls: TLS{Cert: "cert.pem"}},
+		{description: "unknown version", tls: TLS{MinVersion: "1.4"}},
+	}
+
+	for _, test := range tests {
+		_, err := test.tls.Config(".")
+		assert.Error(t, err, test.description)
+	}
+}
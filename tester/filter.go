@@ -0,0 +1,108 @@
+package tester
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Filter selects the contracts of a Test to run.  The zero Filter selects every contract.
+type Filter struct {
+	// Run is a regular expression the names of the contracts must match, nil to select any name
+	Run *regexp.Regexp
+	// Tags are the tags the contracts must have at least one of, empty to select any contract
+	Tags []string
+	// SkipTags are the tags the contracts must not have
+	SkipTags []string
+}
+
+// IsZero returns whether the filter selects every contract
+func (f Filter) IsZero() bool {
+	return f.Run == nil && len(f.Tags) == 0 && len(f.SkipTags) == 0
+}
+
+// selects returns whether the contract is selected by the filter
+func (f Filter) selects(contract Contract) bool {
+	if f.Run != nil && !f.Run.MatchString(contract.Name) {
+		return false
+	}
+	if len(f.Tags) > 0 && !containsAny(contract.Tags, f.Tags) {
+		return false
+	}
+	return !containsAny(contract.Tags, f.SkipTags)
+}
+
+// Filter removes the contracts of the Test of the runner which are not selected by the filter.  A contract which is
+// not selected is kept when a selected contract needs it: when it is the last contract before it writing a variable
+// it reads, in its own fields or in the auth of the Test and the headers of the environment, or when it is named in
+// its DependsOn.  A message is returned for each contract kept this way.  The setup and teardown contracts always run,
+// and are not filtered.
+func (runner *Runner) Filter(f Filter) []string {
+	if f.IsZero() {
+		return nil
+	}
+	t := runner.test
+
+	selected := make([]bool, len(t.Contracts))
+	for i, contract := range t.Contracts {
+		selected[i] = f.selects(contract)
+	}
+
+	// the contracts are visited from the last one, so that the contracts kept are visited in turn for their own needs
+	needed := make(map[int][]string)
+	for j := len(t.Contracts) - 1; j >= 0; j-- {
+		if !selected[j] {
+			continue
+		}
+		contract := t.Contracts[j]
+
+		for name := range variableReferences(runner.prepare(contract)) {
+			if i := lastContractBefore(t.Contracts, j, func(c Contract) bool { _, ok := c.Outputs[name]; return ok }); i >= 0 {
+				selected[i] = true
+				needed[i] = append(needed[i], fmt.Sprintf("%v reads its output %v", contract.Name, name))
+			}
+		}
+		for _, name := range contract.DependsOn {
+			if i := lastContractBefore(t.Contracts, j, func(c Contract) bool { return c.Name == name }); i >= 0 {
+				selected[i] = true
+				needed[i] = append(needed[i], fmt.Sprintf("%v depends on it", contract.Name))
+			}
+		}
+	}
+
+	var contracts []Contract
+	var messages []string
+	for i, contract := range t.Contracts {
+		if !selected[i] {
+			continue
+		}
+		contracts = append(contracts, contract)
+		if reasons := needed[i]; len(reasons) > 0 && !f.selects(contract) {
+			sort.Strings(reasons)
+			messages = append(messages, fmt.Sprintf("contract %v is run although it is not selected: %s", contract.Name, strings.Join(reasons, ", ")))
+		}
+	}
+	t.Contracts = contracts
+
+	return messages
+}
+
+// lastContractBefore returns the index of the last of the contracts before index j which matches, or -1 if none does
+func lastContractBefore(contracts []Contract, j int, matches func(Contract) bool) int {
+	for i := j - 1; i >= 0; i-- {
+		if matches(contracts[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsAny(values, candidates []string) bool {
+	for _, candidate := range candidates {
+		if containsString(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
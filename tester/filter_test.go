@@ -0,0 +1,106 @@
+package tester
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	contracts := []Contract{
+		{Name: "login", Path: "/login", Tags: []string{"auth"}, Outputs: map[string]string{"token": "JSON.token"}},
+		{Name: "health", Path: "/health", Tags: []string{"smoke"}},
+		{Name: "create_order", Path: "/orders?token=::token::", Tags: []string{"orders", "slow"}, Outputs: map[string]string{"order": "JSON.id"}},
+		{Name: "get_order", Path: "/orders/::order::?token=::token::", Tags: []string{"orders", "smoke"}},
+		{Name: "cancel_order", Path: "/orders/cancel", Tags: []string{"orders"}, DependsOn: []string{"get_order"}},
+		{Name: "list_orders", Path: "/orders", Tags: []string{"orders", "slow"}, Locals: map[string]string{"token": "anonymous"}},
+	}
+
+	tests := []struct {
+		description string
+		filter      Filter
+		expected    []string
+		messages    []string
+	}{
+		{
+			description: "the zero filter should select every contract",
+			expected:    []string{"login", "health", "create_order", "get_order", "cancel_order", "list_orders"},
+		},
+		{
+			description: "should select the contracts whose name matches the regular expression",
+			filter:      Filter{Run: regexp.MustCompile("^(login|health)$")},
+			expected:    []string{"login", "health"},
+		},
+		{
+			description: "should select the contracts with any of the tags, and those writing the variables they read",
+			filter:      Filter{Tags: []string{"smoke"}},
+			expected:    []string{"login", "health", "create_order", "get_order"},
+			messages: []string{
+				"contract login is run although it is not selected: create_order reads its output token, get_order reads its output token",
+				"contract create_order is run although it is not selected: get_order reads its output order",
+			},
+		},
+		{
+			description: "should keep the contracts a selected contract depends on, even with a skipped tag",
+			filter:      Filter{Run: regexp.MustCompile("cancel"), SkipTags: []string{"slow"}},
+			expected:    []string{"login", "create_order", "get_order", "cancel_order"},
+			messages: []string{
+				"contract login is run although it is not selected: create_order reads its output token, get_order reads its output token",
+				"contract create_order is run although it is not selected: get_order reads its output order",
+				"contract get_order is run although it is not selected: cancel_order depends on it",
+			},
+		},
+		{
+			description: "should not select the contracts with a skipped tag",
+			filter:      Filter{SkipTags: []string{"orders"}},
+			expected:    []string{"login", "health"},
+		},
+		{
+			description: "should not keep the contracts writing variables the selected contracts set in their locals",
+			filter:      Filter{Run: regexp.MustCompile("^list_orders$")},
+			expected:    []string{"list_orders"},
+		},
+		{
+			description: "should select no contract when none matches",
+			filter:      Filter{Tags: []string{"unknown"}},
+		},
+	}
+
+	for _, tt := range tests {
+		test := &Test{
+			Contracts: append([]Contract{}, contracts...),
+			Setup:     []Contract{{Name: "seed", Tags: []string{"unknown"}}},
+		}
+
+		messages := NewRunner("http://localhost", test).Filter(tt.filter)
+
+		var names []string
+		for _, contract := range test.Contracts {
+			names = append(names, contract.Name)
+		}
+		assert.Equal(t, tt.expected, names, tt.description)
+		assert.Equal(t, tt.messages, messages, tt.description)
+		assert.Len(t, test.Setup, 1, tt.description)
+	}
+}
+
+func TestFilterKeepsTheContractsTheDefaultsRead(t *testing.T) {
+	test := &Test{
+		Auth: &Auth{Bearer: "::token::"},
+		Contracts: []Contract{
+			{Name: "login", Path: "/login", Outputs: map[string]string{"token": "JSON.token"}},
+			{Name: "tenant", Path: "/tenant", Outputs: map[string]string{"tenant": "JSON.id"}},
+			{Name: "me", Path: "/me", Tags: []string{"smoke"}},
+		},
+	}
+	runner := NewRunner("http://localhost", test, WithEnvironment(&Environment{Headers: map[string]string{"X-Tenant": "::tenant::"}}))
+
+	messages := runner.Filter(Filter{Tags: []string{"smoke"}})
+
+	assert.Len(t, test.Contracts, 3, "the contracts writing the variables of the auth and the environment headers should be kept")
+	assert.Equal(t, []string{
+		"contract login is run although it is not selected: me reads its output token, tenant reads its output token",
+		"contract tenant is run although it is not selected: me reads its output tenant",
+	}, messages)
+}
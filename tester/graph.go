@@ -0,0 +1,110 @@
+package tester
+
+import (
+	"fmt"
+	"strings"
+)
+
+// variableReferences returns the names of the variables a contract reads from the runner's variable store.
+// Variables satisfied by the contract's own locals are not included, since locals take precedence.
+func variableReferences(contract Contract) map[string]bool {
+	refs := make(map[string]bool)
+
+	add := func(s string) {
+		for _, match := range variableRegex.FindAllString(s, -1) {
+			name := strings.Trim(match, "::")
+			if _, ok := contract.Locals[name]; ok {
+				continue
+			}
+			refs[name] = true
+		}
+	}
+
+	add(contract.Path)
+	add(contract.Body)
+	for _, value := range contract.Headers {
+		add(value)
+	}
+	if contract.Auth != nil {
+		for _, value := range contract.Auth.values() {
+			add(*value)
+		}
+	}
+
+	return refs
+}
+
+// buildDependencies returns, for each contract, the indexes of the earlier contracts which must have completed
+// before it can run.  A contract depends on an earlier one when it reads a variable the earlier one outputs, when
+// both output the same variable, when it outputs a variable the earlier one reads, or when it names the earlier one
+// in its DependsOn.  Running the contracts in any order which respects these dependencies gives the same results as
+// running them one at a time.
+func buildDependencies(contracts []Contract) [][]int {
+	refs := make([]map[string]bool, len(contracts))
+	for i, contract := range contracts {
+		refs[i] = variableReferences(contract)
+	}
+
+	deps := make([][]int, len(contracts))
+	for j := range contracts {
+		for i := 0; i < j; i++ {
+			if dependsOn(contracts[j], refs[j], contracts[i], refs[i]) {
+				deps[j] = append(deps[j], i)
+			}
+		}
+	}
+
+	return deps
+}
+
+func dependsOn(later Contract, laterRefs map[string]bool, earlier Contract, earlierRefs map[string]bool) bool {
+	if containsString(later.DependsOn, earlier.Name) {
+		return true
+	}
+
+	for name := range earlier.Outputs {
+		if laterRefs[name] {
+			return true
+		}
+		if _, ok := later.Outputs[name]; ok {
+			return true
+		}
+	}
+
+	for name := range later.Outputs {
+		if earlierRefs[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkDependsOn fails when a contract depends on a contract which is not among the earlier ones
+func checkDependsOn(contract Contract, earlier map[string]bool) error {
+	for _, name := range contract.DependsOn {
+		if !earlier[name] {
+			return fmt.Errorf("depends_on %v: no earlier contract has this name", name)
+		}
+	}
+	return nil
+}
+
+// skipReason returns why a contract must be skipped given the results of the contracts which completed before it,
+// or an empty string when it can run
+func skipReason(contract Contract, completed []ContractResult) string {
+	for _, name := range contract.DependsOn {
+		for _, result := range completed {
+			if result.Name != name {
+				continue
+			}
+			if result.Skipped() {
+				return fmt.Sprintf("depends on %v, which was skipped", name)
+			}
+			if result.Err != nil {
+				return fmt.Sprintf("depends on %v, which failed", name)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,236 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var dependencyTests = []struct {
+	contracts   []Contract
+	expected    [][]int
+	description string
+}{
+	{
+		contracts: []Contract{
+			{Path: "/a"},
+			{Path: "/b"},
+		},
+		expected:    [][]int{nil, nil},
+		description: "contracts without variables should be independent",
+	},
+	{
+		contracts: []Contract{
+			{Path: "/login", Outputs: map[string]string{"token": "JSON.token"}},
+			{Path: "/a", Headers: map[string]string{"Authorization": "::token::"}},
+			{Path: "/b?token=::token::"},
+			{Path: "/c", Body: "::token::"},
+		},
+		expected:    [][]int{nil, {0}, {0}, {0}},
+		description: "contracts reading an output should depend on the contract writing it",
+	},
+	{
+		contracts: []Contract{
+			{Path: "/login", Outputs: map[string]string{"token": "JSON.token"}},
+			{Path: "/a?token=::token::", Locals: map[string]string{"token": "local"}},
+		},
+		expected:    [][]int{nil, nil},
+		description: "variables satisfied by locals should not create a dependency",
+	},
+	{
+		contracts: []Contract{
+			{Path: "/a", Outputs: map[string]string{"id": "JSON.id"}},
+			{Path: "/b?id=::id::"},
+			{Path: "/c", Outputs: map[string]string{"id": "JSON.id"}},
+		},
+		expected:    [][]int{nil, {0}, {0, 1}},
+		description: "a contract overwriting an output should run after the previous writers and readers",
+	},
+	{
+		contracts: []Contract{
+			{Name: "create", Path: "/a"},
+			{Name: "other", Path: "/b"},
+			{Name: "delete", Path: "/c", DependsOn: []string{"create"}},
+		},
+		expected:    [][]int{nil, nil, {0}},
+		description: "a contract should depend on the contracts named in its depends_on",
+	},
+}
+
+func TestBuildDependencies(t *testing.T) {
+	for _, tt := range dependencyTests {
+		assert.Equal(t, tt.expected, buildDependencies(tt.contracts), tt.description)
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		if r.URL.Path == "/login" {
+			fmt.Fprint(w, `{"token": "abc"}`)
+			return
+		}
+		if r.URL.Query().Get("token") != "abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	test := &Test{
+		Globals: map[string]string{},
+		Contracts: []Contract{
+			{Name: "login", Path: "/login", Method: "GET", Outputs: map[string]string{"token": "JSON.token"}},
+		},
+	}
+	for i := 0; i < 8; i++ {
+		test.Contracts = append(test.Contracts, Contract{
+			Name:             fmt.Sprintf("contract_%d", i),
+			Path:             "/resource?token=::token::",
+			Method:           "GET",
+			ExpectedHTTPCode: http.StatusOK,
+		})
+	}
+
+	runner := NewRunner(server.URL, test, WithParallelism(4))
+
+	assert.True(t, runner.Run(), "all contracts should pass when dependencies are respected")
+	assert.True(t, atomic.LoadInt32(&maxInFlight) > 1, "independent contracts should run concurrently")
+	assert.True(t, atomic.LoadInt32(&maxInFlight) <= 4, "no more contracts than the parallelism should run at once")
+	assert.Empty(t, test.Globals, "the test globals should not be modified by outputs")
+}
+
+func TestRunParallelSharedSettings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status": "done"}`)
+	}))
+	defer server.Close()
+
+	// the contracts of a Test which was not created by NewTest share their retry and the array of their expected
+	// responses, which must not be written by the contracts running concurrently
+	retry := &Retry{Attempts: 2, Until: &RetryCondition{JSONBodyMatches: map[string]interface{}{"status": "done"}}}
+	expected := make([]string, 1, 8)
+	expected[0] = "status"
+
+	test := &Test{}
+	for i := 0; i < 8; i++ {
+		test.Contracts = append(test.Contracts, Contract{
+			Name:                 fmt.Sprintf("contract_%d", i),
+			Path:                 "/",
+			Method:               "GET",
+			Retry:                retry,
+			ExpectedResponses:    expected,
+			ExpectedResponseBody: "done",
+		})
+	}
+
+	assert.True(t, NewRunner(server.URL, test, WithParallelism(8)).Run())
+}
+
+func TestDependsOn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	for _, parallelism := range []int{1, 4} {
+		test := &Test{
+			Contracts: []Contract{
+				{Name: "login", Path: "/login", ExpectedHTTPCode: 200, Outputs: map[string]string{"token": "header.X-Token"}},
+				{Name: "profile", Path: "/profile?token=::token::", DependsOn: []string{"login"}},
+				{Name: "orders", Path: "/orders", DependsOn: []string{"profile"}},
+				{Name: "health", Path: "/health", ExpectedHTTPCode: 200},
+			},
+			Teardown: []Contract{{Name: "logout", Path: "/logout", DependsOn: []string{"login"}}},
+		}
+		assert.NoError(t, test.init())
+
+		reporter := &recordingReporter{}
+		assert.False(t, NewRunner(server.URL, test, WithParallelism(parallelism), WithReporter(reporter)).Run())
+
+		skipped := make(map[string]string)
+		for _, result := range reporter.results {
+			skipped[result.Name] = result.SkipReason
+		}
+		assert.Equal(t, map[string]string{
+			"login":   "",
+			"profile": "depends on login, which failed",
+			"orders":  "depends on profile, which was skipped",
+			"health":  "",
+			"logout":  "depends on login, which failed",
+		}, skipped, "parallelism %d", parallelism)
+
+		assert.Equal(t, 5, reporter.suite.Total)
+		assert.Equal(t, 1, reporter.suite.Failed)
+		assert.Equal(t, 3, reporter.suite.Skipped)
+		assert.Equal(t, 1, reporter.suite.Passed())
+	}
+}
+
+func TestFailFast(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	test := &Test{
+		Contracts: []Contract{
+			{Name: "first", Path: "/first", ExpectedHTTPCode: 200},
+			{Name: "broken", Path: "/broken", ExpectedHTTPCode: 200},
+			{Name: "third", Path: "/third", ExpectedHTTPCode: 200},
+			{Name: "fourth", Path: "/fourth", ExpectedHTTPCode: 200},
+		},
+		Teardown: []Contract{{Name: "cleanup", Path: "/cleanup", ExpectedHTTPCode: 200}},
+	}
+	assert.NoError(t, test.init())
+
+	reporter := &recordingReporter{}
+	assert.False(t, NewRunner(server.URL, test, WithFailFast(true), WithReporter(reporter)).Run())
+
+	// the teardown still runs
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Equal(t, []string{
+		"suite started  5",
+		"contract started first",
+		"contract finished first",
+		"contract started broken",
+		"contract finished broken",
+		"contract finished third",
+		"contract finished fourth",
+		"contract started cleanup",
+		"contract finished cleanup",
+		"suite finished ",
+	}, reporter.events)
+	assert.Equal(t, "stopped after contract broken failed", reporter.results[2].SkipReason)
+	assert.Equal(t, "stopped after contract broken failed", reporter.results[3].SkipReason)
+	assert.Equal(t, 2, reporter.suite.Skipped)
+}
+
+func TestInvalidDependsOn(t *testing.T) {
+	test := &Test{
+		Setup:     []Contract{{Name: "seed", DependsOn: []string{"login"}}},
+		Contracts: []Contract{{Name: "login"}, {Name: "profile", DependsOn: []string{"login", "seed"}}},
+		Teardown:  []Contract{{Name: "logout", DependsOn: []string{"login"}}},
+	}
+	assert.EqualError(t, test.init(), "setup contract seed: depends_on login: no earlier contract has this name")
+}
@@ -0,0 +1,286 @@
+package tester
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// loadTest reads a test file and the files it includes.  stack holds the absolute paths of the files being loaded,
+// to detect include cycles.
+func loadTest(inputFile string, stack map[string]bool) (*Test, error) {
+	abs, err := filepath.Abs(inputFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read test file %v", inputFile)
+	}
+	if stack[abs] {
+		return nil, fmt.Errorf("test file %v includes itself", inputFile)
+	}
+	stack[abs] = true
+	defer delete(stack, abs)
+
+	data, err := ioutil.ReadFile(inputFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read test file %v", inputFile)
+	}
+
+	t := &Test{dir: filepath.Dir(inputFile)}
+	if err := unmarshalInputFile(inputFile, data, t); err != nil {
+		return nil, errors.Wrapf(err, "could not unmarshal test data in %v", inputFile)
+	}
+
+	// schema files are relative to the file defining the contract or template referencing them
+	for _, phase := range t.phases() {
+		for i := range phase.contracts {
+			phase.contracts[i].schemaDir = t.dir
+			phase.contracts[i].file = inputFile
+		}
+	}
+	for name, template := range t.Templates {
+		template.schemaDir = t.dir
+		t.Templates[name] = template
+	}
+	for _, env := range t.Environments {
+		if env != nil {
+			env.dir = t.dir
+		}
+	}
+
+	for _, pattern := range t.Include {
+		files, err := includedFiles(t.dir, pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid include in %v", inputFile)
+		}
+
+		for _, file := range files {
+			included, err := loadTest(file, stack)
+			if err != nil {
+				return nil, err
+			}
+			if err := t.merge(included); err != nil {
+				return nil, errors.Wrapf(err, "could not include %v in %v", file, inputFile)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// includedFiles returns the files matching an include pattern, relative to dir
+func includedFiles(dir, pattern string) ([]string, error) {
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	files, err := glob(pattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern %v", pattern)
+	}
+	if len(files) == 0 && !isPattern(pattern) {
+		return nil, fmt.Errorf("file %v not found", pattern)
+	}
+
+	return files, nil
+}
+
+// merge adds the globals, templates, environments and contracts of an included test.  Globals and environments
+// already defined are kept, the contracts of every phase are appended, and the default retry, auth and response time
+// of the included test apply to its own contracts.
+func (t *Test) merge(included *Test) error {
+	for key, value := range included.Globals {
+		if t.Globals == nil {
+			t.Globals = make(map[string]string)
+		}
+		if _, ok := t.Globals[key]; !ok {
+			t.Globals[key] = value
+		}
+	}
+
+	for name, template := range included.Templates {
+		if t.Templates == nil {
+			t.Templates = make(map[string]Contract)
+		}
+		if _, ok := t.Templates[name]; ok {
+			return fmt.Errorf("template %v is already defined", name)
+		}
+		t.Templates[name] = template
+	}
+
+	for name, env := range included.Environments {
+		if t.Environments == nil {
+			t.Environments = make(map[string]*Environment)
+		}
+		if _, ok := t.Environments[name]; !ok {
+			t.Environments[name] = env
+		}
+	}
+
+	t.Setup = append(t.Setup, included.withDefaults(included.Setup)...)
+	t.Contracts = append(t.Contracts, included.withDefaults(included.Contracts)...)
+	t.Teardown = append(t.Teardown, included.withDefaults(included.Teardown)...)
+
+	return nil
+}
+
+// withDefaults returns the contracts with the defaults of the Test they do not define themselves
+func (t *Test) withDefaults(contracts []Contract) []Contract {
+	var result []Contract
+	for _, contract := range contracts {
+		if contract.Retry == nil {
+			contract.Retry = t.Retry
+		}
+		if contract.Auth == nil {
+			contract.Auth = t.Auth
+		}
+		if contract.ResponseTimeUnder == nil {
+			contract.ResponseTimeUnder = t.ResponseTimeUnder
+		}
+		result = append(result, contract)
+	}
+	return result
+}
+
+// resolveTemplates replaces every contract extending a template by the combination of the two
+func (t *Test) resolveTemplates() error {
+	for _, phase := range t.phases() {
+		for i := range phase.contracts {
+			if phase.contracts[i].Extends == "" {
+				continue
+			}
+
+			extended, err := t.extend(phase.contracts[i], map[string]bool{})
+			if err != nil {
+				return errors.Wrap(err, phase.describe(phase.contracts[i].Name))
+			}
+			phase.contracts[i] = extended
+		}
+	}
+
+	return nil
+}
+
+// extend returns the contract combined with the template it extends, and the templates that one extends in turn
+func (t *Test) extend(contract Contract, seen map[string]bool) (Contract, error) {
+	name := contract.Extends
+	if name == "" {
+		return contract, nil
+	}
+	if seen[name] {
+		return contract, fmt.Errorf("template %v extends itself", name)
+	}
+	seen[name] = true
+
+	template, ok := t.Templates[name]
+	if !ok {
+		return contract, fmt.Errorf("template %v not found", name)
+	}
+
+	template, err := t.extend(template, seen)
+	if err != nil {
+		return contract, err
+	}
+
+	return inherit(contract, template), nil
+}
+
+// inherit returns the contract with the fields it does not define taken from the template.  Maps are merged, with
+// the values of the contract taking precedence, and the expected responses of both are checked.
+func inherit(contract, template Contract) Contract {
+	if contract.Path == "" {
+		contract.Path = template.Path
+	}
+	if contract.Method == "" {
+		contract.Method = template.Method
+	}
+	if contract.Body == "" {
+		contract.Body = template.Body
+	}
+
+	contract.Headers = mergeStrings(template.Headers, contract.Headers)
+	contract.Locals = mergeStrings(template.Locals, contract.Locals)
+	contract.Outputs = mergeStrings(template.Outputs, contract.Outputs)
+	if len(template.DependsOn) > 0 {
+		contract.DependsOn = append(append([]string{}, template.DependsOn...), contract.DependsOn...)
+	}
+	if len(template.Tags) > 0 {
+		contract.Tags = append(append([]string{}, template.Tags...), contract.Tags...)
+	}
+
+	if contract.ExpectedHTTPCode == 0 {
+		contract.ExpectedHTTPCode = template.ExpectedHTTPCode
+	}
+	expected := template.ExpectedResponses
+	if template.ExpectedResponseBody != "" {
+		expected = append(append([]string{}, expected...), template.ExpectedResponseBody)
+	}
+	if len(expected) > 0 {
+		contract.ExpectedResponses = append(append([]string{}, expected...), contract.ExpectedResponses...)
+	}
+	contract.ExpectedHeaders = mergeStrings(template.ExpectedHeaders, contract.ExpectedHeaders)
+
+	if len(template.JSONBodyMatches) > 0 {
+		matches := make(map[string]interface{}, len(template.JSONBodyMatches)+len(contract.JSONBodyMatches))
+		for k, v := range template.JSONBodyMatches {
+			matches[k] = v
+		}
+		for k, v := range contract.JSONBodyMatches {
+			matches[k] = v
+		}
+		contract.JSONBodyMatches = matches
+	}
+
+	if contract.ResponseSchema == nil && template.ResponseSchema != nil {
+		contract.ResponseSchema = template.ResponseSchema
+		contract.schemaDir = template.schemaDir
+	}
+	if contract.Retry == nil {
+		contract.Retry = template.Retry
+	}
+	if contract.Auth == nil {
+		contract.Auth = template.Auth
+	}
+	if contract.PeerCertificate == nil {
+		contract.PeerCertificate = template.PeerCertificate
+	}
+	if contract.ResponseTimeUnder == nil {
+		contract.ResponseTimeUnder = template.ResponseTimeUnder
+	}
+	if len(template.ExpectedCookies) > 0 {
+		cookies := make(map[string]*CookieAssertion, len(template.ExpectedCookies)+len(contract.ExpectedCookies))
+		for k, v := range template.ExpectedCookies {
+			cookies[k] = v
+		}
+		for k, v := range contract.ExpectedCookies {
+			cookies[k] = v
+		}
+		contract.ExpectedCookies = cookies
+	}
+	contract.ResetCookies = contract.ResetCookies || template.ResetCookies
+
+	if contract.FollowRedirects == nil {
+		contract.FollowRedirects = template.FollowRedirects
+	}
+	if contract.RedirectChain == nil {
+		contract.RedirectChain = template.RedirectChain
+	}
+
+	return contract
+}
+
+// mergeStrings returns the values of base overridden by the values of override, or nil if both are empty
+func mergeStrings(base, override map[string]string) map[string]string {
+	if len(base) == 0 {
+		return override
+	}
+
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
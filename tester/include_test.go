@@ -0,0 +1,185 @@
+package tester
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestFiles writes files, keyed by their path relative to a new temporary directory, and returns that directory
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "smoke")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestInclude(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"main.yaml": `
+name: main
+include:
+- common.yaml
+- users/*.yaml
+globals:
+  token: main
+contracts:
+- name: main
+  path: /main
+`,
+		"common.yaml": `
+globals:
+  token: common
+  user: ann
+templates:
+  api:
+    method: GET
+    headers:
+      Authorization: "Bearer ::token::"
+contracts:
+- name: common
+  path: /common
+`,
+		"users/b.yaml": `
+contracts:
+- name: users_b
+  path: /users/b
+`,
+		"users/a.yaml": `
+retry:
+  attempts: 3
+contracts:
+- name: users_a
+  extends: api
+  path: /users/a
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	test, err := NewTest(filepath.Join(dir, "main.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "main", test.Name)
+	assert.Equal(t, map[string]string{"token": "main", "user": "ann"}, test.Globals)
+
+	var names []string
+	for _, contract := range test.Contracts {
+		names = append(names, contract.Name)
+	}
+	assert.Equal(t, []string{"main", "common", "users_a", "users_b"}, names)
+
+	usersA := test.Contracts[2]
+	assert.Equal(t, "GET", usersA.Method)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer ::token::"}, usersA.Headers)
+	if assert.NotNil(t, usersA.Retry) {
+		assert.Equal(t, 3, usersA.Retry.Attempts)
+	}
+	assert.Nil(t, test.Contracts[3].Retry)
+}
+
+func TestIncludeErrors(t *testing.T) {
+	tests := []struct {
+		description string
+		files       map[string]string
+	}{
+		{
+			description: "missing file",
+			files: map[string]string{
+				"main.yaml": "include: [missing.yaml]",
+			},
+		},
+		{
+			description: "cycle",
+			files: map[string]string{
+				"main.yaml":  "include: [other.yaml]",
+				"other.yaml": "include: [main.yaml]",
+			},
+		},
+		{
+			description: "duplicate template",
+			files: map[string]string{
+				"main.yaml":  "include: [other.yaml]\ntemplates:\n  api:\n    method: GET",
+				"other.yaml": "templates:\n  api:\n    method: POST",
+			},
+		},
+		{
+			description: "unknown template",
+			files: map[string]string{
+				"main.yaml": "contracts:\n- name: c\n  extends: api",
+			},
+		},
+		{
+			description: "template cycle",
+			files: map[string]string{
+				"main.yaml": "templates:\n  a:\n    extends: b\n  b:\n    extends: a\ncontracts:\n- name: c\n  extends: a",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		dir := writeTestFiles(t, test.files)
+
+		_, err := NewTest(filepath.Join(dir, "main.yaml"))
+		assert.Error(t, err, test.description)
+
+		os.RemoveAll(dir)
+	}
+}
+
+func TestInherit(t *testing.T) {
+	tests := []struct {
+		description string
+		contract    Contract
+		template    Contract
+		expected    Contract
+	}{
+		{
+			description: "contract fields are taken from the template when not defined",
+			contract:    Contract{Name: "c", Path: "/c"},
+			template:    Contract{Path: "/t", Method: "POST", Body: "{}", ExpectedHTTPCode: 201},
+			expected:    Contract{Name: "c", Path: "/c", Method: "POST", Body: "{}", ExpectedHTTPCode: 201},
+		},
+		{
+			description: "maps are merged with the contract taking precedence",
+			contract: Contract{
+				Headers:         map[string]string{"Accept": "text/plain"},
+				JSONBodyMatches: map[string]interface{}{"id": 2},
+			},
+			template: Contract{
+				Headers:         map[string]string{"Accept": "application/json", "X-Api": "1"},
+				JSONBodyMatches: map[string]interface{}{"id": 1, "name": "ann"},
+			},
+			expected: Contract{
+				Headers:         map[string]string{"Accept": "text/plain", "X-Api": "1"},
+				JSONBodyMatches: map[string]interface{}{"id": 2, "name": "ann"},
+			},
+		},
+		{
+			description: "expected responses of both are checked",
+			contract:    Contract{ExpectedResponses: []string{"c"}},
+			template:    Contract{ExpectedResponseBody: "t", ExpectedResponses: []string{"u"}},
+			expected:    Contract{ExpectedResponses: []string{"u", "t", "c"}},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, inherit(test.contract, test.template), test.description)
+	}
+}
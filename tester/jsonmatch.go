@@ -0,0 +1,302 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonMatcher is a compiled assertion on the value found at a path of a JSON response body
+type jsonMatcher struct {
+	path *jsonPath
+
+	equals    interface{}
+	hasEquals bool
+
+	notEquals    interface{}
+	hasNotEquals bool
+
+	exists *bool
+	absent *bool
+
+	typeIs string
+	length *int
+
+	greaterThan interface{}
+	lessThan    interface{}
+
+	regex *regexp.Regexp
+}
+
+var jsonMatcherOperators = map[string]bool{
+	"equals":       true,
+	"not_equals":   true,
+	"exists":       true,
+	"absent":       true,
+	"type":         true,
+	"length":       true,
+	"greater_than": true,
+	"less_than":    true,
+	"regex":        true,
+}
+
+var jsonTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"number":  true,
+	"string":  true,
+	"array":   true,
+	"object":  true,
+}
+
+// compileJSONMatchers compiles the json_body_matches assertions of a contract, sorted by path.
+//
+// Each expected value is either a map of operators to their argument, or any other value which the value at the
+// path must be equal to.
+func compileJSONMatchers(assertions map[string]interface{}) ([]jsonMatcher, error) {
+	paths := make([]string, 0, len(assertions))
+	for path := range assertions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	matchers := make([]jsonMatcher, 0, len(assertions))
+	for _, path := range paths {
+		m, err := compileJSONMatcher(path, normalizeValue(assertions[path]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid assertion for %q", path)
+		}
+		matchers = append(matchers, m)
+	}
+
+	return matchers, nil
+}
+
+func compileJSONMatcher(path string, expected interface{}) (jsonMatcher, error) {
+	var m jsonMatcher
+
+	p, err := compileJSONPath(trimJSONPrefix(path))
+	if err != nil {
+		return m, err
+	}
+	m.path = p
+
+	operators, ok := expected.(map[string]interface{})
+	if !ok || len(operators) == 0 || !onlyOperators(operators) {
+		m.equals, m.hasEquals = expected, true
+		return m, nil
+	}
+
+	for op, arg := range operators {
+		switch op {
+		case "equals":
+			m.equals, m.hasEquals = arg, true
+		case "not_equals":
+			m.notEquals, m.hasNotEquals = arg, true
+		case "exists", "absent":
+			b, ok := arg.(bool)
+			if !ok {
+				return m, fmt.Errorf("%s expects true or false, got %v", op, formatJSONValue(arg))
+			}
+			if op == "exists" {
+				m.exists = &b
+			} else {
+				m.absent = &b
+			}
+		case "type":
+			s, ok := arg.(string)
+			if !ok || !jsonTypes[s] {
+				return m, fmt.Errorf("type expects one of null, boolean, number, string, array or object, got %v", formatJSONValue(arg))
+			}
+			m.typeIs = s
+		case "length":
+			f, ok := toFloat(arg)
+			if !ok || f < 0 || f != float64(int(f)) {
+				return m, fmt.Errorf("length expects a positive integer, got %v", formatJSONValue(arg))
+			}
+			n := int(f)
+			m.length = &n
+		case "greater_than", "less_than":
+			if _, ok := toFloat(arg); !ok {
+				return m, fmt.Errorf("%s expects a number, got %v", op, formatJSONValue(arg))
+			}
+			if op == "greater_than" {
+				m.greaterThan = arg
+			} else {
+				m.lessThan = arg
+			}
+		case "regex":
+			s, ok := arg.(string)
+			if !ok {
+				return m, fmt.Errorf("regex expects a string, got %v", formatJSONValue(arg))
+			}
+			re, err := regexp.Compile(s)
+			if err != nil {
+				return m, errors.Wrap(err, "invalid regular expression")
+			}
+			m.regex = re
+		}
+	}
+
+	return m, nil
+}
+
+func onlyOperators(m map[string]interface{}) bool {
+	for key := range m {
+		if !jsonMatcherOperators[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// trimJSONPrefix removes the JSON. prefix used by outputs, which is optional in assertions
+func trimJSONPrefix(path string) string {
+	if len(path) > 5 && strings.EqualFold(path[:5], "json.") {
+		return path[5:]
+	}
+	return path
+}
+
+// match returns a description of every assertion which does not hold for doc
+func (m jsonMatcher) match(doc interface{}) []string {
+	var failures []string
+	fail := func(format string, args ...interface{}) {
+		failures = append(failures, fmt.Sprintf("json path %q: ", m.path.expression)+fmt.Sprintf(format, args...))
+	}
+
+	actual, err := m.path.evaluate(doc)
+	found := err == nil
+
+	var notFound string
+	if pathErr, ok := err.(*PathError); ok {
+		notFound = fmt.Sprintf("at %q: %s", pathErr.Segment, pathErr.Reason)
+	}
+
+	if m.absent != nil && *m.absent == found {
+		if found {
+			fail("expected to be absent, got %s", formatExpected(actual))
+		} else {
+			fail("expected to be present, %s", notFound)
+		}
+	}
+
+	if m.exists != nil && *m.exists != found {
+		if found {
+			fail("expected not to exist, got %s", formatExpected(actual))
+		} else {
+			fail("expected to exist, %s", notFound)
+		}
+	}
+
+	if !found {
+		if m.hasEquals || m.hasNotEquals || m.typeIs != "" || m.length != nil || m.greaterThan != nil || m.lessThan != nil || m.regex != nil {
+			fail("%s", notFound)
+		}
+		return failures
+	}
+
+	if m.hasEquals && !jsonEqual(actual, m.equals) {
+		fail("expected %s, got %s", formatExpected(m.equals), formatExpected(actual))
+	}
+
+	if m.hasNotEquals && jsonEqual(actual, m.notEquals) {
+		fail("expected not equal to %s, got %s", formatExpected(m.notEquals), formatExpected(actual))
+	}
+
+	if m.typeIs != "" && jsonType(actual) != m.typeIs {
+		fail("expected type %s, got %s %s", m.typeIs, jsonType(actual), formatExpected(actual))
+	}
+
+	if m.length != nil {
+		if n, err := length(actual); err != nil {
+			fail("expected length %d, %v", *m.length, err)
+		} else if n != *m.length {
+			fail("expected length %d, got %d", *m.length, n)
+		}
+	}
+
+	if m.greaterThan != nil {
+		if c, ok := jsonCompare(actual, m.greaterThan); !ok || c <= 0 {
+			fail("expected greater than %s, got %s", formatExpected(m.greaterThan), formatExpected(actual))
+		}
+	}
+
+	if m.lessThan != nil {
+		if c, ok := jsonCompare(actual, m.lessThan); !ok || c >= 0 {
+			fail("expected less than %s, got %s", formatExpected(m.lessThan), formatExpected(actual))
+		}
+	}
+
+	if m.regex != nil && !m.regex.MatchString(formatJSONValue(actual)) {
+		fail("expected to match regular expression %s, got %s", m.regex, formatExpected(actual))
+	}
+
+	return failures
+}
+
+// formatExpected formats a value for failure messages, quoting strings so that they can be told apart from numbers
+func formatExpected(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return formatJSONValue(v)
+}
+
+// normalizeValue converts a value decoded from a YAML or JSON test file to the types used when decoding a JSON
+// response: maps with string keys and json.Number for numbers
+func normalizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			m[fmt.Sprint(k)] = normalizeValue(v)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, v := range val {
+			m[k] = normalizeValue(v)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(val))
+		for i, v := range val {
+			s[i] = normalizeValue(v)
+		}
+		return s
+	case int, int64, uint64, float64:
+		return json.Number(fmt.Sprint(val))
+	}
+	return v
+}
+
+func validateJSONBody(contract Contract, body []byte) error {
+	matchers := contract.jsonMatchers
+	if matchers == nil {
+		var err error
+		if matchers, err = compileJSONMatchers(contract.JSONBodyMatches); err != nil {
+			return err
+		}
+	}
+
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return errors.Wrap(err, "could not parse response body as json")
+	}
+
+	var failures []string
+	for _, m := range matchers {
+		failures = append(failures, m.match(doc)...)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package tester
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+const jsonMatchBody = `{
+	"id": 3,
+	"name": "widget",
+	"price": 9.99,
+	"active": true,
+	"deleted_at": null,
+	"tags": ["a", "b"],
+	"owner": {"id": 7, "email": "owner@example.com"}
+}`
+
+var jsonMatchTests = []struct {
+	assertions  string
+	expectedErr string
+	description string
+}{
+	{
+		assertions:  `{id: 3, name: widget, price: 9.99, active: true, deleted_at: null, tags: [a, b], owner: {id: 7, email: owner@example.com}}`,
+		description: "should pass when all the values are equal",
+	},
+	{
+		assertions:  `{JSON.owner.id: 7}`,
+		description: "should accept the JSON. prefix used by outputs",
+	},
+	{
+		assertions: `{
+			id: {not_equals: 4, greater_than: 2, less_than: 4, type: number},
+			name: {regex: "^wid", length: 6},
+			tags: {length: 2, type: array},
+			owner.email: {exists: true},
+			missing: {absent: true},
+			deleted_at: {exists: true, type: "null"}
+		}`,
+		description: "should pass when all the operators hold",
+	},
+	{
+		assertions:  `{"tags[?@==b]": [b], "tags[?@==b][0]": b}`,
+		description: "should compare a filter with a list, or one of its matches with a value, as in the outputs",
+	},
+	{
+		assertions:  `{"tags[?@==b]": b}`,
+		expectedErr: `json path "tags[?@==b]": expected "b", got ["b"]`,
+		description: "should not compare a filter matching a single element with a value",
+	},
+	{
+		assertions:  `{id: 4}`,
+		expectedErr: `json path "id": expected 4, got 3`,
+		description: "should name the path, the expected and the actual value",
+	},
+	{
+		assertions:  `{id: "3"}`,
+		expectedErr: `json path "id": expected "3", got 3`,
+		description: "should not consider a string equal to a number",
+	},
+	{
+		assertions:  `{name: {not_equals: widget}, owner: {id: 8, email: owner@example.com}}`,
+		expectedErr: `json path "name": expected not equal to "widget", got "widget"; json path "owner": expected {"email":"owner@example.com","id":8}, got {"email":"owner@example.com","id":7}`,
+		description: "should report every failing path",
+	},
+	{
+		assertions:  `{tags: {length: 3}, price: {greater_than: 10}}`,
+		expectedErr: `json path "price": expected greater than 10, got 9.99; json path "tags": expected length 3, got 2`,
+		description: "should report length and comparison failures",
+	},
+	{
+		assertions:  `{owner.id: {type: string}}`,
+		expectedErr: `json path "owner.id": expected type string, got number 7`,
+		description: "should report type failures",
+	},
+	{
+		assertions:  `{owner.email: {absent: true}}`,
+		expectedErr: `json path "owner.email": expected to be absent, got "owner@example.com"`,
+		description: "should fail when an absent value is present",
+	},
+	{
+		assertions:  `{owner.phone: {exists: true}}`,
+		expectedErr: `json path "owner.phone": expected to exist, at "phone": key not found`,
+		description: "should fail when an existing value is missing",
+	},
+	{
+		assertions:  `{owner.phone: "555"}`,
+		expectedErr: `json path "owner.phone": at "phone": key not found`,
+		description: "should fail when the path is not found",
+	},
+}
+
+func TestValidateJSONBody(t *testing.T) {
+	for _, tt := range jsonMatchTests {
+		contract := Contract{}
+		if !assert.NoError(t, yaml.Unmarshal([]byte(tt.assertions), &contract.JSONBodyMatches), tt.description) {
+			continue
+		}
+
+		err := validateJSONBody(contract, []byte(jsonMatchBody))
+
+		if tt.expectedErr == "" {
+			assert.NoError(t, err, tt.description)
+		} else {
+			assert.EqualError(t, err, tt.expectedErr, tt.description)
+		}
+	}
+}
+
+func TestCompileJSONMatchersErrors(t *testing.T) {
+	for _, assertions := range []map[string]interface{}{
+		{"id": map[interface{}]interface{}{"regex": "[a-"}},
+		{"id": map[interface{}]interface{}{"type": "integer"}},
+		{"id": map[interface{}]interface{}{"length": -1}},
+		{"id": map[interface{}]interface{}{"greater_than": "a"}},
+		{"id": map[interface{}]interface{}{"exists": "yes"}},
+		{"id[": 1},
+	} {
+		_, err := compileJSONMatchers(assertions)
+		assert.Error(t, err, "%v should not compile", assertions)
+	}
+}
+
+func TestValidateJSONBodyNotJSON(t *testing.T) {
+	err := validateJSONBody(Contract{JSONBodyMatches: map[string]interface{}{"id": 1}}, []byte("<html></html>"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,560 @@
+package tester
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PathError is returned when a JSON path expression is invalid, or cannot be evaluated against a document
+type PathError struct {
+	// Path is the full expression
+	Path string
+	// Segment is the part of the expression where the error occurred
+	Segment string
+	// Reason describes the error
+	Reason string
+}
+
+func (e *PathError) Error() string {
+	if e.Segment == "" {
+		return fmt.Sprintf("json path %q: %s", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("json path %q: at %q: %s", e.Path, e.Segment, e.Reason)
+}
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepIndex
+	stepWildcard
+	stepFilter
+	stepLength
+)
+
+type pathStep struct {
+	kind    stepKind
+	segment string
+
+	field  string
+	index  int
+	filter *pathFilter
+}
+
+type pathFilter struct {
+	// left is evaluated against each element of the filtered array, or is nil to use the element itself
+	left *jsonPath
+	// op is empty when the filter only checks that left exists
+	op    string
+	right interface{}
+}
+
+// jsonPath is a compiled JSON path expression.
+//
+// The syntax is a dotted list of object keys, where each key can be followed by any number of brackets:
+//   - `a.b.c` selects nested object keys.  `a["b.c"]` selects a key containing dots or brackets.
+//   - `a[1]` selects an array element, `a[-1]` counting from the end.  `a[0][2]` selects from nested arrays.
+//   - `a[*]` and `a.*` select every element of an array or every value of an object.
+//   - `a[?id==3]` selects the elements of an array matching a condition.  The operators are ==, !=, <, <=, >, >=
+//     and the left side is a path relative to the element, or @ for the element itself.  `a[?id]` selects the
+//     elements where id exists.  `a[?id==3][0]` selects one of the matching elements.
+//   - `a.length()` is the length of an array, object or string.
+//
+// After a wildcard or a filter, the following steps apply to every selected element and the result is a list, even
+// when a single element is selected.  An index right after a filter is the exception, it selects from the matches.
+type jsonPath struct {
+	expression string
+	steps      []pathStep
+}
+
+func compileJSONPath(expression string) (*jsonPath, error) {
+	p := &jsonPath{expression: expression}
+	if strings.TrimSpace(expression) == "" {
+		return nil, &PathError{Path: expression, Reason: "empty expression"}
+	}
+
+	s := expression
+	first := true
+	for len(s) > 0 {
+		switch {
+		case s[0] == '[':
+			end, err := closingBracket(s)
+			if err != nil {
+				return nil, &PathError{Path: expression, Segment: s, Reason: err.Error()}
+			}
+			step, err := parseBracket(s[:end+1])
+			if err != nil {
+				return nil, &PathError{Path: expression, Segment: s[:end+1], Reason: err.Error()}
+			}
+			p.steps = append(p.steps, step)
+			s = s[end+1:]
+
+		case s[0] == '.' || first:
+			if s[0] == '.' {
+				s = s[1:]
+			}
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			name := s[:end]
+			switch name {
+			case "":
+				return nil, &PathError{Path: expression, Segment: s, Reason: "missing key name"}
+			case "*":
+				p.steps = append(p.steps, pathStep{kind: stepWildcard, segment: name})
+			case "length()":
+				p.steps = append(p.steps, pathStep{kind: stepLength, segment: name})
+			default:
+				p.steps = append(p.steps, pathStep{kind: stepField, segment: name, field: name})
+			}
+			s = s[end:]
+
+		default:
+			return nil, &PathError{Path: expression, Segment: s, Reason: "expected '.' or '['"}
+		}
+		first = false
+	}
+
+	return p, nil
+}
+
+// closingBracket returns the index of the bracket closing the one s starts with, skipping quoted strings and
+// nested brackets
+func closingBracket(s string) (int, error) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed bracket")
+}
+
+func parseBracket(segment string) (pathStep, error) {
+	content := strings.TrimSpace(segment[1 : len(segment)-1])
+
+	switch {
+	case content == "":
+		return pathStep{}, fmt.Errorf("empty brackets")
+
+	case content == "*":
+		return pathStep{kind: stepWildcard, segment: segment}, nil
+
+	case content[0] == '?':
+		filter, err := parseFilter(strings.TrimSpace(content[1:]))
+		if err != nil {
+			return pathStep{}, err
+		}
+		return pathStep{kind: stepFilter, segment: segment, filter: filter}, nil
+
+	case isQuoted(content):
+		return pathStep{kind: stepField, segment: segment, field: content[1 : len(content)-1]}, nil
+	}
+
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return pathStep{}, fmt.Errorf("invalid index %q", content)
+	}
+	return pathStep{kind: stepIndex, segment: segment, index: index}, nil
+}
+
+var filterOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func parseFilter(condition string) (*pathFilter, error) {
+	if condition == "" {
+		return nil, fmt.Errorf("empty filter")
+	}
+
+	left, op, right := condition, "", ""
+	var quote byte
+	for i := 0; i < len(condition) && op == ""; i++ {
+		c := condition[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '\'' || c == '"' {
+			quote = c
+			continue
+		}
+		for _, candidate := range filterOperators {
+			if strings.HasPrefix(condition[i:], candidate) {
+				left, op, right = condition[:i], candidate, condition[i+len(candidate):]
+				break
+			}
+		}
+	}
+
+	filter := &pathFilter{op: op}
+
+	left = strings.TrimSpace(left)
+	left = strings.TrimPrefix(left, "@")
+	left = strings.TrimPrefix(left, ".")
+	if left != "" {
+		p, err := compileJSONPath(left)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter %q: %v", condition, err)
+		}
+		filter.left = p
+	} else if op == "" {
+		return nil, fmt.Errorf("invalid filter %q", condition)
+	}
+
+	if op != "" {
+		right = strings.TrimSpace(right)
+		if right == "" {
+			return nil, fmt.Errorf("invalid filter %q: missing value after %s", condition, op)
+		}
+		filter.right = parseLiteral(right)
+	}
+
+	return filter, nil
+}
+
+func isQuoted(s string) bool {
+	return len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0]
+}
+
+// parseLiteral parses the value on the right side of a filter: a quoted string, a number, true, false or null.
+// Anything else is taken as an unquoted string.
+func parseLiteral(s string) interface{} {
+	switch {
+	case isQuoted(s):
+		return s[1 : len(s)-1]
+	case s == "true":
+		return true
+	case s == "false":
+		return false
+	case s == "null":
+		return nil
+	}
+
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return json.Number(s)
+	}
+
+	return s
+}
+
+// evaluate returns the value selected by the path in doc.  When the path contains a wildcard or a filter, value is a
+// []interface{} of every selected element.
+func (p *jsonPath) evaluate(doc interface{}) (interface{}, error) {
+	values := []interface{}{doc}
+	projected := false
+
+	for i, step := range p.steps {
+		if step.kind == stepLength {
+			n := len(values)
+			if !projected {
+				var err error
+				if n, err = length(values[0]); err != nil {
+					return nil, p.error(step, err.Error())
+				}
+			}
+			values, projected = []interface{}{json.Number(strconv.Itoa(n))}, false
+			continue
+		}
+
+		// an index right after a filter selects one of the matching elements
+		if step.kind == stepIndex && i > 0 && p.steps[i-1].kind == stepFilter {
+			selected, err := p.apply(step, values)
+			if err != nil {
+				return nil, err
+			}
+			values, projected = selected, false
+			continue
+		}
+
+		var next []interface{}
+		for _, v := range values {
+			selected, err := p.apply(step, v)
+			if err != nil {
+				if projected {
+					// elements of a projection which do not match the rest of the path are left out
+					continue
+				}
+				return nil, err
+			}
+			next = append(next, selected...)
+		}
+
+		if step.kind == stepWildcard || step.kind == stepFilter {
+			projected = true
+		}
+		values = next
+	}
+
+	if projected {
+		if values == nil {
+			values = []interface{}{}
+		}
+		return values, nil
+	}
+
+	return values[0], nil
+}
+
+func (p *jsonPath) apply(step pathStep, v interface{}) ([]interface{}, error) {
+	switch step.kind {
+	case stepField:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, p.error(step, fmt.Sprintf("expected an object, got %s", jsonType(v)))
+		}
+		val, ok := m[step.field]
+		if !ok {
+			return nil, p.error(step, "key not found")
+		}
+		return []interface{}{val}, nil
+
+	case stepIndex:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, p.error(step, fmt.Sprintf("expected an array, got %s", jsonType(v)))
+		}
+		i := step.index
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return nil, p.error(step, fmt.Sprintf("index out of range for array of length %d", len(arr)))
+		}
+		return []interface{}{arr[i]}, nil
+
+	case stepWildcard:
+		switch val := v.(type) {
+		case []interface{}:
+			return val, nil
+		case map[string]interface{}:
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			values := make([]interface{}, 0, len(val))
+			for _, k := range keys {
+				values = append(values, val[k])
+			}
+			return values, nil
+		}
+		return nil, p.error(step, fmt.Sprintf("expected an array or an object, got %s", jsonType(v)))
+
+	case stepFilter:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, p.error(step, fmt.Sprintf("expected an array, got %s", jsonType(v)))
+		}
+		var values []interface{}
+		for _, elem := range arr {
+			if step.filter.match(elem) {
+				values = append(values, elem)
+			}
+		}
+		return values, nil
+	}
+
+	return nil, p.error(step, "unsupported step")
+}
+
+func (p *jsonPath) error(step pathStep, reason string) error {
+	return &PathError{Path: p.expression, Segment: step.segment, Reason: reason}
+}
+
+func (f *pathFilter) match(elem interface{}) bool {
+	left := elem
+	if f.left != nil {
+		val, err := f.left.evaluate(elem)
+		if err != nil {
+			return false
+		}
+		left = val
+	}
+
+	if f.op == "" {
+		return true
+	}
+
+	switch f.op {
+	case "==":
+		return jsonEqual(left, f.right)
+	case "!=":
+		return !jsonEqual(left, f.right)
+	}
+
+	c, ok := jsonCompare(left, f.right)
+	if !ok {
+		return false
+	}
+	switch f.op {
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+
+	return false
+}
+
+func length(v interface{}) (int, error) {
+	switch val := v.(type) {
+	case []interface{}:
+		return len(val), nil
+	case map[string]interface{}:
+		return len(val), nil
+	case string:
+		return len([]rune(val)), nil
+	}
+	return 0, fmt.Errorf("cannot take the length of %s", jsonType(v))
+}
+
+// jsonEqual reports whether two decoded JSON values are equal, comparing numbers by value
+func jsonEqual(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+
+	switch av := a.(type) {
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !jsonEqual(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			if w, ok := bv[k]; !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+// jsonCompare orders two numbers or two strings.  ok is false if the values cannot be ordered.
+func jsonCompare(a, b interface{}) (c int, ok bool) {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	as, ok := a.(string)
+	if !ok {
+		return 0, false
+	}
+	bs, ok := b.(string)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(as, bs), true
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// jsonType returns the JSON name of the type of a decoded value
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number, float64, int:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// decodeJSON decodes a JSON document, keeping numbers as json.Number so that they are formatted as they were received
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+
+	return doc, nil
+}
+
+// formatJSONValue returns the string representation of a decoded value used for variables: strings and numbers as
+// they are, anything else encoded as JSON
+func formatJSONValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
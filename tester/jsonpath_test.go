@@ -0,0 +1,198 @@
+package tester
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const jsonPathDocument = `{
+	"id": 12345678901,
+	"name": "list",
+	"empty": null,
+	"matrix": [[1, 2, 3], [4, 5, 6]],
+	"items": [
+		{"id": 1, "name": "one", "tags": ["a"], "price": 9.5},
+		{"id": 2, "name": "two", "tags": ["a", "b"], "price": 20},
+		{"id": 3, "name": "three", "tags": [], "price": 15, "discount": true}
+	],
+	"dotted.key": "dotted"
+}`
+
+var jsonPathTests = []struct {
+	expression    string
+	expectedValue string
+	expectedError bool
+	description   string
+}{
+	{
+		expression:    "id",
+		expectedValue: "12345678901",
+		description:   "should keep large numbers as they were received",
+	},
+	{
+		expression:    "empty",
+		expectedValue: "null",
+		description:   "should return null values",
+	},
+	{
+		expression:    "matrix[1][2]",
+		expectedValue: "6",
+		description:   "should index nested arrays",
+	},
+	{
+		expression:    "items[-1].name",
+		expectedValue: "three",
+		description:   "should index from the end of the array with negative indexes",
+	},
+	{
+		expression:    "items[1].tags",
+		expectedValue: `["a","b"]`,
+		description:   "should encode arrays as json",
+	},
+	{
+		expression:    "items[*].name",
+		expectedValue: `["one","two","three"]`,
+		description:   "should project the rest of the path over a wildcard",
+	},
+	{
+		expression:    "items.*.id",
+		expectedValue: `[1,2,3]`,
+		description:   "should accept wildcards as a key",
+	},
+	{
+		expression:    "items[?id==3].name",
+		expectedValue: `["three"]`,
+		description:   "should return a list when a filter matches a single element",
+	},
+	{
+		expression:    "items[?id==3][0].name",
+		expectedValue: "three",
+		description:   "should select one of the matching elements with an index after the filter",
+	},
+	{
+		expression:    "items[?price>=15][-1].id",
+		expectedValue: "3",
+		description:   "should select the matching elements from the end with a negative index",
+	},
+	{
+		expression:    "items[?name=='two'].id",
+		expectedValue: "[2]",
+		description:   "should filter on quoted strings",
+	},
+	{
+		expression:    "items[?price>=15].name",
+		expectedValue: `["two","three"]`,
+		description:   "should filter on ordered comparisons",
+	},
+	{
+		expression:    "items[?discount].name",
+		expectedValue: `["three"]`,
+		description:   "should filter on the existence of a key",
+	},
+	{
+		expression:    "items[?tags[1]==b].id",
+		expectedValue: "[2]",
+		description:   "should filter with a nested path and an unquoted string",
+	},
+	{
+		expression:    "items[?id>5].name",
+		expectedValue: `[]`,
+		description:   "should return an empty list when no element matches",
+	},
+	{
+		expression:    "matrix[*][0]",
+		expectedValue: `[1,4]`,
+		description:   "should index every element of a projection",
+	},
+	{
+		expression:    "items.length()",
+		expectedValue: "3",
+		description:   "should return the length of an array",
+	},
+	{
+		expression:    "name.length()",
+		expectedValue: "4",
+		description:   "should return the length of a string",
+	},
+	{
+		expression:    "items[?price>10].length()",
+		expectedValue: "2",
+		description:   "should return the number of elements of a projection",
+	},
+	{
+		expression:    `["dotted.key"]`,
+		expectedValue: "dotted",
+		description:   "should select quoted keys",
+	},
+	{
+		expression:    "items[?id>5][0]",
+		expectedError: true,
+		description:   "should return an error when no element matches the filter before an index",
+	},
+	{
+		expression:    "items[3]",
+		expectedError: true,
+		description:   "should return an error when the index is out of range",
+	},
+	{
+		expression:    "items[-4]",
+		expectedError: true,
+		description:   "should return an error when the negative index is out of range",
+	},
+	{
+		expression:    "name[0]",
+		expectedError: true,
+		description:   "should return an error when indexing something which is not an array",
+	},
+	{
+		expression:    "name.first",
+		expectedError: true,
+		description:   "should return an error when selecting a key in something which is not an object",
+	},
+	{
+		expression:    "id.length()",
+		expectedError: true,
+		description:   "should return an error when taking the length of a number",
+	},
+	{
+		expression:    "items[abc]",
+		expectedError: true,
+		description:   "should return an error for an invalid index",
+	},
+	{
+		expression:    "items[0",
+		expectedError: true,
+		description:   "should return an error for an unclosed bracket",
+	},
+	{
+		expression:    "items..id",
+		expectedError: true,
+		description:   "should return an error for an empty key",
+	},
+	{
+		expression:    "items[?id==]",
+		expectedError: true,
+		description:   "should return an error for an incomplete filter",
+	},
+}
+
+func TestJSONPath(t *testing.T) {
+	for _, tt := range jsonPathTests {
+		v, err := parseJSON(tt.expression, []byte(jsonPathDocument))
+
+		assert.True(t, (err != nil) == tt.expectedError, tt.description)
+		assert.Equal(t, tt.expectedValue, v, tt.description)
+	}
+}
+
+func TestJSONPathError(t *testing.T) {
+	_, err := parseJSON("items[5].id", []byte(jsonPathDocument))
+
+	pathErr, ok := err.(*PathError)
+	if assert.True(t, ok, "errors should be returned as *PathError") {
+		assert.Equal(t, "items[5].id", pathErr.Path)
+		assert.Equal(t, "[5]", pathErr.Segment)
+		assert.Equal(t, `json path "items[5].id": at "[5]": index out of range for array of length 3`, err.Error())
+	}
+}
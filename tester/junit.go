@@ -0,0 +1,134 @@
+package tester
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// JUnitReporter is a Reporter which collects the results of one or more test suites and writes them as a
+// JUnit XML report, with one testsuite per Test and one testcase per Contract.
+type JUnitReporter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	suites  []junitTestSuite
+	started time.Time
+}
+
+// NewJUnitReporter returns a *JUnitReporter which writes its report to out when Flush is called
+func NewJUnitReporter(out io.Writer) *JUnitReporter {
+	return &JUnitReporter{out: out}
+}
+
+// SuiteStarted implements Reporter
+func (r *JUnitReporter) SuiteStarted(name string, contracts int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.started = time.Now()
+}
+
+// ContractStarted implements Reporter
+func (r *JUnitReporter) ContractStarted(contract Contract) {}
+
+// ContractFinished implements Reporter
+func (r *JUnitReporter) ContractFinished(result ContractResult) {}
+
+// SuiteFinished implements Reporter
+func (r *JUnitReporter) SuiteFinished(result SuiteResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	suite := junitTestSuite{
+		Name:      result.Name,
+		Tests:     result.Total,
+		Failures:  result.Failed,
+		Skipped:   result.Skipped,
+		Time:      junitSeconds(result.Duration),
+		Timestamp: r.started.Format("2006-01-02T15:04:05"),
+	}
+
+	for _, contract := range result.Contracts {
+		testCase := junitTestCase{
+			Name:      contract.displayName(),
+			ClassName: result.Name,
+			Time:      junitSeconds(contract.Duration),
+		}
+		if contract.Timings != nil {
+			testCase.SystemOut = "timings: " + contract.Timings.String()
+		}
+		if contract.Skipped() {
+			testCase.Skipped = &junitSkipped{Message: contract.SkipReason}
+		}
+		if contract.Err != nil {
+			testCase.Failure = &junitFailure{
+				Message: contract.Err.Error(),
+				Content: contract.Err.Error(),
+			}
+			if contract.Attempts > 1 {
+				testCase.Failure.Content = fmt.Sprintf("failed after %d attempts: %v", contract.Attempts, contract.Err)
+			}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	r.suites = append(r.suites, suite)
+}
+
+// Flush writes the report of every suite finished so far
+func (r *JUnitReporter) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := io.WriteString(r.out, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(r.out)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: r.suites}); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(r.out, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,39 @@
+package tester
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitReporter(t *testing.T) {
+	out := &bytes.Buffer{}
+	reporter := NewJUnitReporter(out)
+
+	reporter.SuiteStarted("smoke_test.yaml", 3)
+	reporter.SuiteFinished(SuiteResult{
+		Name:     "smoke_test.yaml",
+		Total:    3,
+		Failed:   1,
+		Skipped:  1,
+		Duration: 1500 * time.Millisecond,
+		Contracts: []ContractResult{
+			{Name: "ok", Duration: 500 * time.Millisecond},
+			{Name: "ko", Duration: time.Second, Err: errors.New(`expected http response code 200 got 500`)},
+			{Name: "next", SkipReason: "depends on ko, which failed"},
+		},
+	})
+
+	assert.NoError(t, reporter.Flush())
+
+	report := out.String()
+	assert.Contains(t, report, `<testsuite name="smoke_test.yaml" tests="3" failures="1" skipped="1" time="1.500"`)
+	assert.Contains(t, report, `<testcase name="ok" classname="smoke_test.yaml" time="0.500"></testcase>`)
+	assert.Contains(t, report, `<testcase name="ko" classname="smoke_test.yaml" time="1.000">`)
+	assert.Contains(t, report, `<testcase name="next" classname="smoke_test.yaml" time="0.000">`)
+	assert.Contains(t, report, `<skipped message="depends on ko, which failed"></skipped>`)
+	assert.Contains(t, report, `<failure message="expected http response code 200 got 500">expected http response code 200 got 500</failure>`)
+}
@@ -0,0 +1,405 @@
+package tester
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LintIssue is a problem found in a test file by Lint
+type LintIssue struct {
+	File string
+	// Line is the line of the problem in File, starting at 1, or 0 when it is not known
+	Line    int
+	Message string
+}
+
+func (i LintIssue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s", i.File, i.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+}
+
+var (
+	yamlLineRegex  = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+	yamlFieldRegex = regexp.MustCompile(`^field (\S+) not found in type (?:\w+\.)?(\w+)$`)
+	jsonFieldRegex = regexp.MustCompile(`^json: unknown field "(.*)"$`)
+)
+
+var httpMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// Lint checks a test file and the files it includes without running it.  Unknown fields, invalid settings and
+// expressions, unknown methods and variables which are not defined by the locals, the globals, an environment, the
+// outputs of an earlier contract or the environment of the process are reported, in the order of the files.
+func Lint(inputFile string) []LintIssue {
+	l := &linter{lines: make(map[string][]string)}
+	l.decode(inputFile, make(map[string]bool))
+
+	t, err := loadTest(inputFile, make(map[string]bool))
+	if err != nil {
+		// the problem was already reported with its line when the file was decoded
+		if len(l.issues) == 0 {
+			l.add(inputFile, 0, "%v", err)
+		}
+		return l.sorted()
+	}
+
+	l.checkSettings(t)
+	l.checkContracts(t)
+
+	return l.sorted()
+}
+
+type linter struct {
+	issues []LintIssue
+	// files are the files read, in order, and lines their content
+	files []string
+	lines map[string][]string
+}
+
+func (l *linter) add(file string, line int, format string, args ...interface{}) {
+	l.issues = append(l.issues, LintIssue{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+// sorted returns the issues by file, in the order they were read, then by line
+func (l *linter) sorted() []LintIssue {
+	order := make(map[string]int, len(l.files))
+	for i, file := range l.files {
+		order[file] = i
+	}
+
+	sort.SliceStable(l.issues, func(i, j int) bool {
+		a, b := l.issues[i], l.issues[j]
+		if order[a.File] != order[b.File] {
+			return order[a.File] < order[b.File]
+		}
+		return a.Line < b.Line
+	})
+
+	return l.issues
+}
+
+// decode strictly decodes a test file and the files it includes, reporting the fields which are not known
+func (l *linter) decode(file string, seen map[string]bool) {
+	abs, err := filepath.Abs(file)
+	if err != nil || seen[abs] {
+		return
+	}
+	seen[abs] = true
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		l.add(file, 0, "could not read test file: %v", err)
+		return
+	}
+	l.files = append(l.files, file)
+	l.lines[file] = strings.Split(string(data), "\n")
+
+	var t Test
+	switch ext := strings.Trim(path.Ext(file), "."); ext {
+	case "yaml", "yml":
+		l.addYAMLErrors(file, yaml.UnmarshalStrict(data, &t))
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		l.addJSONError(file, data, decoder.Decode(&t))
+	}
+
+	for _, pattern := range t.Include {
+		files, err := includedFiles(filepath.Dir(file), pattern)
+		if err != nil {
+			l.add(file, l.find(file, 0, 0, pattern), "include: %v", err)
+			continue
+		}
+		for _, included := range files {
+			l.decode(included, seen)
+		}
+	}
+}
+
+func (l *linter) addYAMLErrors(file string, err error) {
+	if err == nil {
+		return
+	}
+
+	messages := []string{err.Error()}
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	}
+
+	for _, message := range messages {
+		line := 0
+		if match := yamlLineRegex.FindStringSubmatch(message); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			message = match[2]
+		}
+		if match := yamlFieldRegex.FindStringSubmatch(message); match != nil {
+			message = fmt.Sprintf("unknown field %q in %s", match[1], match[2])
+		}
+		l.add(file, line, "%s", message)
+	}
+}
+
+func (l *linter) addJSONError(file string, data []byte, err error) {
+	line := func(offset int64) int {
+		if offset > int64(len(data)) {
+			offset = int64(len(data))
+		}
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+
+	switch e := err.(type) {
+	case nil:
+	case *json.SyntaxError:
+		l.add(file, line(e.Offset), "%v", e)
+	case *json.UnmarshalTypeError:
+		l.add(file, line(e.Offset), "%v", e)
+	default:
+		if match := jsonFieldRegex.FindStringSubmatch(err.Error()); match != nil {
+			l.add(file, l.find(file, 0, 0, strconv.Quote(match[1])+":", strconv.Quote(match[1])+" :"), "unknown field %q", match[1])
+			return
+		}
+		l.add(file, 0, "%v", err)
+	}
+}
+
+// find returns the first line between from and to (excluded, 0 for the end of the file) of a file which contains one
+// of the needles, counting from 1, or from+1 when none does
+func (l *linter) find(file string, from, to int, needles ...string) int {
+	lines := l.lines[file]
+	if to <= 0 || to > len(lines) {
+		to = len(lines)
+	}
+	for i := from; i < to; i++ {
+		for _, needle := range needles {
+			if needle != "" && strings.Contains(lines[i], needle) {
+				return i + 1
+			}
+		}
+	}
+	if from < len(lines) {
+		return from + 1
+	}
+	return 0
+}
+
+// locate returns the first file and line containing the needle, starting with the file of the Test
+func (l *linter) locate(needle string) (string, int) {
+	for _, file := range l.files {
+		for i, line := range l.lines[file] {
+			if strings.Contains(line, needle) {
+				return file, i + 1
+			}
+		}
+	}
+	if len(l.files) > 0 {
+		return l.files[0], 0
+	}
+	return "", 0
+}
+
+func (l *linter) checkSettings(t *Test) {
+	report := func(needle string, err error) {
+		file, line := l.locate(needle)
+		l.add(file, line, "%v", err)
+	}
+
+	if t.Auth != nil {
+		if err := t.Auth.init(); err != nil {
+			report("auth", fmt.Errorf("auth: %v", err))
+		}
+	}
+	if t.TLS != nil {
+		if _, err := t.TLS.Config(t.dir); err != nil {
+			report("tls", fmt.Errorf("tls: %v", err))
+		}
+	}
+	if t.Retry != nil {
+		if err := t.Retry.init(); err != nil {
+			report("retry", fmt.Errorf("retry: %v", err))
+		}
+	}
+
+	names := make([]string, 0, len(t.Environments))
+	for name := range t.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env := t.Environments[name]
+		if env == nil {
+			report(name, fmt.Errorf("environment %v: no settings", name))
+			continue
+		}
+		if err := env.init(t.dir); err != nil {
+			report(name, fmt.Errorf("environment %v: %v", name, err))
+		}
+	}
+}
+
+// contractLines returns the line index of the name of each contract of a phase in the file defining it
+func (l *linter) contractLines(phase contractPhase) []int {
+	key := "contracts"
+	if phase.name != "" {
+		key = phase.name
+	}
+	keyRegex := regexp.MustCompile(`^\s*"?` + key + `"?\s*:`)
+
+	contracts := phase.contracts
+	cursors := make(map[string]int)
+	starts := make([]int, len(contracts))
+
+	for i, contract := range contracts {
+		lines := l.lines[contract.file]
+		cursor, ok := cursors[contract.file]
+		if !ok {
+			for j, line := range lines {
+				if keyRegex.MatchString(line) {
+					cursor = j
+					break
+				}
+			}
+		}
+
+		starts[i] = cursor
+		for j := cursor; j < len(lines); j++ {
+			if isNameLine(lines[j], contract.Name) {
+				starts[i] = j
+				cursor = j + 1
+				break
+			}
+		}
+		cursors[contract.file] = cursor
+	}
+
+	return starts
+}
+
+// isNameLine returns whether a line of a test file sets the name of a contract to name
+func isNameLine(line, name string) bool {
+	line = strings.TrimLeft(strings.TrimSpace(line), "- {")
+	var value string
+	switch {
+	case strings.HasPrefix(line, "name:"):
+		value = line[len("name:"):]
+	case strings.HasPrefix(line, `"name"`):
+		value = strings.TrimLeft(line[len(`"name"`):], " :")
+	default:
+		return false
+	}
+	value = strings.TrimRight(strings.TrimSpace(value), ",}")
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	}
+	return strings.Trim(value, "'") == name
+}
+
+func (l *linter) checkContracts(t *Test) {
+	// the variables the contracts can read, other than their own locals and the environment of the process
+	defined := make(map[string]bool)
+	for name := range t.Globals {
+		defined[name] = true
+	}
+	for _, env := range t.Environments {
+		if env == nil {
+			continue
+		}
+		for name := range env.Globals {
+			defined[name] = true
+		}
+	}
+
+	// the outputs of the setup are defined for the contracts and the teardown, which may also depend on the setup
+	earlier := make(map[string]bool)
+	for _, phase := range t.phases() {
+		l.checkPhase(t, phase, defined, earlier)
+	}
+}
+
+func (l *linter) checkPhase(t *Test, phase contractPhase, defined, earlier map[string]bool) {
+	starts := l.contractLines(phase)
+
+	for i, contract := range phase.contracts {
+		file, start := contract.file, starts[i]
+		end := 0
+		for j := i + 1; j < len(phase.contracts); j++ {
+			if phase.contracts[j].file == file {
+				end = starts[j]
+				break
+			}
+		}
+
+		report := func(needle string, format string, args ...interface{}) {
+			line := start + 1
+			if needle != "" {
+				line = l.find(file, start, end, needle)
+			}
+			l.add(file, line, "%s: %s", phase.describe(contract.Name), fmt.Sprintf(format, args...))
+		}
+
+		if contract.Extends != "" {
+			extended, err := t.extend(contract, make(map[string]bool))
+			if err != nil {
+				report("extends", "%v", err)
+				earlier[contract.Name] = true
+				continue
+			}
+			contract = extended
+		}
+
+		if contract.Method != "" && !containsString(httpMethods, strings.ToUpper(contract.Method)) {
+			report(contract.Method, "unknown method %s", contract.Method)
+		}
+
+		for _, err := range t.initContract(&contract) {
+			// the messages begin by the field in error
+			field := ""
+			if fields := strings.FieldsFunc(err.Error(), func(r rune) bool { return r == ':' || r == ' ' }); len(fields) > 0 {
+				field = fields[0]
+			}
+			report(field, "%v", err)
+		}
+		if err := checkDependsOn(contract, earlier); err != nil {
+			report("depends_on", "%v", err)
+		}
+		earlier[contract.Name] = true
+
+		// the contract is sent with the auth of the Test and the headers of the environment it is run in
+		refs := variableReferences((&Runner{test: t}).prepare(contract))
+		for _, env := range t.Environments {
+			for name := range variableReferences((&Runner{test: t, environment: env}).prepare(contract)) {
+				refs[name] = true
+			}
+		}
+		names := make([]string, 0, len(refs))
+		for name := range refs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if defined[name] || os.Getenv(strings.ToUpper(name)) != "" {
+				continue
+			}
+			report("::"+name+"::", "variable %s is not defined by its locals, the globals, an environment or the outputs of an earlier contract", name)
+		}
+
+		for name := range contract.Outputs {
+			defined[name] = true
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package tester
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"valid.yaml": `
+name: valid
+globals:
+  host: example.com
+environments:
+  staging:
+    globals:
+      user: ann
+contracts:
+  - name: login
+    method: post
+    path: "/login/::user::"
+    headers:
+      Host: "::host::"
+    outputs:
+      token: JSON.token
+  - name: profile
+    path: /profile
+    headers:
+      Authorization: "Bearer ::token::"
+    response_body_contains: r/"id":\s*\d+
+`,
+		"unknown_field.yaml": `
+contracts:
+  - name: get
+    path: /get
+    http_code: 200
+`,
+		"undefined.yaml": `
+contracts:
+  - name: profile
+    path: /profile
+    headers:
+      Authorization: "Bearer ::token::"
+  - name: login
+    path: /login
+    outputs:
+      token: JSON.token
+  - name: local
+    path: "/items/::id::"
+    locals:
+      id: "1"
+`,
+		"defaults.yaml": `
+auth:
+  bearer: "::nowhere::"
+environments:
+  staging:
+    headers:
+      X-Tenant: "::tenant::"
+      X-Trace: "::trace::"
+contracts:
+  - name: ping
+    path: /ping
+    headers:
+      X-Trace: fixed
+`,
+		"phases.yaml": `
+setup:
+  - name: login
+    path: /login
+    outputs:
+      token: JSON.token
+contracts:
+  - name: profile
+    path: /profile
+    headers:
+      Authorization: "Bearer ::token::"
+teardown:
+  - name: logout
+    method: remove
+    path: "/logout/::session::"
+    depends_on: [login, signup]
+`,
+		"invalid.yaml": `
+contracts:
+  - name: regex
+    path: /get
+    response_body_contains: r/(
+  - name: headers
+    path: /get
+    response_headers_contain:
+      Content-Type: r/[
+  - name: method
+    method: FETCH
+    path: /get
+  - name: output
+    path: /get
+    outputs:
+      token: body.token
+  - name: template
+    extends: missing
+  - name: both
+    path: /get
+    response_body_contains: r/(
+    outputs:
+      token: body.token
+`,
+		"main.yaml": `
+include:
+  - common.yaml
+contracts:
+  - name: get
+    path: /get
+`,
+		"common.yaml": `
+contracts:
+  - name: common
+    path: "/::undefined::"
+    response_code: 200
+`,
+		"unknown_field.json": `{
+  "contracts": [
+    {
+      "name": "get",
+      "path": "/get",
+      "http_code": 200
+    }
+  ]
+}`,
+		"syntax.json": `{
+  "contracts": [
+    {"name": "get",}
+  ]
+}`,
+		"syntax.yaml": `
+contracts:
+  - name: get
+   path: /get
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	file := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	tests := []struct {
+		description string
+		file        string
+		expected    []LintIssue
+	}{
+		{
+			description: "should not report anything for a valid file",
+			file:        "valid.yaml",
+		},
+		{
+			description: "should report an unknown field",
+			file:        "unknown_field.yaml",
+			expected: []LintIssue{
+				{File: file("unknown_field.yaml"), Line: 5, Message: `unknown field "http_code" in Contract`},
+			},
+		},
+		{
+			description: "should report a variable which is not defined before the contract using it",
+			file:        "undefined.yaml",
+			expected: []LintIssue{
+				{File: file("undefined.yaml"), Line: 6, Message: "contract profile: variable token is not defined by its locals, the globals, an environment or the outputs of an earlier contract"},
+			},
+		},
+		{
+			description: "should report the variables of the auth of the Test and the headers of the environments",
+			file:        "defaults.yaml",
+			expected: []LintIssue{
+				{File: file("defaults.yaml"), Line: 10, Message: "contract ping: variable nowhere is not defined by its locals, the globals, an environment or the outputs of an earlier contract"},
+				{File: file("defaults.yaml"), Line: 10, Message: "contract ping: variable tenant is not defined by its locals, the globals, an environment or the outputs of an earlier contract"},
+			},
+		},
+		{
+			description: "should report invalid expressions and methods",
+			file:        "invalid.yaml",
+			expected: []LintIssue{
+				{File: file("invalid.yaml"), Line: 5, Message: "contract regex: response_body_contains r/(: invalid regular expression: error parsing regexp: missing closing ): `(`"},
+				{File: file("invalid.yaml"), Line: 8, Message: "contract headers: response_headers_contain Content-Type: invalid regular expression: error parsing regexp: missing closing ]: `[`"},
+				{File: file("invalid.yaml"), Line: 11, Message: "contract method: unknown method FETCH"},
+				{File: file("invalid.yaml"), Line: 15, Message: `contract output: outputs: invalid output token: unknown source body in "body.token", expected json, header, status, cookie, regex or xpath`},
+				{File: file("invalid.yaml"), Line: 18, Message: "contract template: template missing not found"},
+				{File: file("invalid.yaml"), Line: 21, Message: "contract both: response_body_contains r/(: invalid regular expression: error parsing regexp: missing closing ): `(`"},
+				{File: file("invalid.yaml"), Line: 22, Message: `contract both: outputs: invalid output token: unknown source body in "body.token", expected json, header, status, cookie, regex or xpath`},
+			},
+		},
+		{
+			description: "should report the problems of included files",
+			file:        "main.yaml",
+			expected: []LintIssue{
+				{File: file("common.yaml"), Line: 4, Message: "contract common: variable undefined is not defined by its locals, the globals, an environment or the outputs of an earlier contract"},
+				{File: file("common.yaml"), Line: 5, Message: `unknown field "response_code" in Contract`},
+			},
+		},
+		{
+			description: "should check the setup and teardown, with the outputs of the setup defined for the other contracts",
+			file:        "phases.yaml",
+			expected: []LintIssue{
+				{File: file("phases.yaml"), Line: 14, Message: "teardown contract logout: unknown method remove"},
+				{File: file("phases.yaml"), Line: 15, Message: "teardown contract logout: variable session is not defined by its locals, the globals, an environment or the outputs of an earlier contract"},
+				{File: file("phases.yaml"), Line: 16, Message: "teardown contract logout: depends_on signup: no earlier contract has this name"},
+			},
+		},
+		{
+			description: "should report an unknown field of a json file",
+			file:        "unknown_field.json",
+			expected: []LintIssue{
+				{File: file("unknown_field.json"), Line: 6, Message: `unknown field "http_code"`},
+			},
+		},
+		{
+			description: "should report a json syntax error",
+			file:        "syntax.json",
+			expected: []LintIssue{
+				{File: file("syntax.json"), Line: 3, Message: "invalid character '}' looking for beginning of object key string"},
+			},
+		},
+		{
+			description: "should report a yaml syntax error",
+			file:        "syntax.yaml",
+			expected: []LintIssue{
+				{File: file("syntax.yaml"), Line: 3, Message: "did not find expected '-' indicator"},
+			},
+		},
+		{
+			description: "should report a missing file",
+			file:        "missing.yaml",
+			expected: []LintIssue{
+				{File: file("missing.yaml"), Message: "could not read test file: open " + file("missing.yaml") + ": no such file or directory"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, Lint(file(test.file)), test.description)
+	}
+}
+
+func TestLintIssueString(t *testing.T) {
+	assert.Equal(t, "test.yaml:3: unknown method FETCH", LintIssue{File: "test.yaml", Line: 3, Message: "unknown method FETCH"}.String())
+	assert.Equal(t, "test.yaml: no such file", LintIssue{File: "test.yaml", Message: "no such file"}.String())
+}
@@ -0,0 +1,326 @@
+package tester
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LoadOptions holds the settings of a load test.  At least one of Duration and Iterations must be set, and the load
+// test stops as soon as either is reached.
+type LoadOptions struct {
+	// Users is the number of virtual users running the contracts concurrently.  Each user runs the contracts in
+	// order, with its own variables and cookies.
+	Users int
+	// Duration is the time after which the users stop
+	Duration time.Duration
+	// Iterations is the number of times the contracts are run in total, by all the users
+	Iterations int
+	// RPS is the maximum number of requests per second sent by all the users, or 0 for no limit
+	RPS float64
+}
+
+// LoadResult holds the outcome of a load test
+type LoadResult struct {
+	Name       string
+	Users      int
+	Iterations int
+	Duration   time.Duration
+	Contracts  []ContractLoadResult
+}
+
+// ContractLoadResult holds the outcome of running a single contract under load
+type ContractLoadResult struct {
+	Name string
+	// Requests is the number of times the contract was run, and Failed how many of those failed
+	Requests int
+	Failed   int
+	// Skipped is the number of times the contract was not run, because a contract it depends on did not pass in the
+	// same iteration
+	Skipped int
+	// Failures counts the failures by error message
+	Failures map[string]int
+	// Latencies are the response times of every run of the contract, in increasing order
+	Latencies []time.Duration
+}
+
+// Percentile returns the latency under which p percent of the runs of the contract completed
+func (r ContractLoadResult) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(r.Latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(r.Latencies) {
+		rank = len(r.Latencies)
+	}
+	return r.Latencies[rank-1]
+}
+
+// Failed returns the number of failed runs of every contract
+func (r *LoadResult) Failed() int {
+	failed := 0
+	for _, contract := range r.Contracts {
+		failed += contract.Failed
+	}
+	return failed
+}
+
+// Requests returns the number of runs of every contract
+func (r *LoadResult) Requests() int {
+	requests := 0
+	for _, contract := range r.Contracts {
+		requests += contract.Requests
+	}
+	return requests
+}
+
+// Load runs the contracts of the Test repeatedly with several virtual users, and returns the latencies and failures
+// of each contract.  The contracts are checked exactly as with Run, but the results are not given to the reporters.
+// The setup and teardown contracts run once, before and after the load test, and an error is returned if any of them
+// fails, along with the result of the load test if it was run.
+func (runner *Runner) Load(opts LoadOptions) (result *LoadResult, err error) {
+	if opts.Users < 1 {
+		return nil, fmt.Errorf("expected at least 1 user, got %d", opts.Users)
+	}
+	if opts.Duration <= 0 && opts.Iterations <= 0 {
+		return nil, fmt.Errorf("expected a duration or a number of iterations")
+	}
+	if opts.RPS < 0 {
+		return nil, fmt.Errorf("expected a positive number of requests per second, got %v", opts.RPS)
+	}
+
+	// the teardown runs even when the setup failed
+	defer func() {
+		for _, contract := range runner.test.Teardown {
+			contract = runner.prepare(contract)
+			if teardownErr := runner.validateContract(contract, &ContractResult{Name: contract.Name}); teardownErr != nil && err == nil {
+				err = errors.Wrapf(teardownErr, "teardown contract %v", contract.Name)
+			}
+		}
+	}()
+
+	for _, contract := range runner.test.Setup {
+		contract = runner.prepare(contract)
+		if err := runner.validateContract(contract, &ContractResult{Name: contract.Name}); err != nil {
+			return nil, errors.Wrapf(err, "setup contract %v", contract.Name)
+		}
+	}
+
+	contracts := make([]Contract, len(runner.test.Contracts))
+	for i, contract := range runner.test.Contracts {
+		contracts[i] = runner.prepare(contract)
+	}
+
+	stats := make([]*contractLoadStats, len(contracts))
+	for i, contract := range contracts {
+		stats[i] = &contractLoadStats{result: ContractLoadResult{Name: contract.Name, Failures: make(map[string]int)}}
+	}
+
+	// done is closed once the duration is over, the users then stop after their current request
+	done := make(chan struct{})
+	if opts.Duration > 0 {
+		timer := time.AfterFunc(opts.Duration, func() { close(done) })
+		defer timer.Stop()
+	}
+
+	// the ticks are shared by all the users, each request waiting for one of them
+	var ticks <-chan time.Time
+	if opts.RPS > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.RPS))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	var iterations int64
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for u := 0; u < opts.Users; u++ {
+		wg.Add(1)
+		go func(user *Runner) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				// the other users finish the iterations they started
+				if n := atomic.AddInt64(&iterations, 1); opts.Iterations > 0 && n > int64(opts.Iterations) {
+					return
+				}
+
+				// the results of the iteration, for the contracts depending on the earlier ones
+				completed := make([]ContractResult, 0, len(contracts))
+				for i, contract := range contracts {
+					if reason := skipReason(contract, completed); reason != "" {
+						result := ContractResult{Name: contract.Name, SkipReason: reason}
+						stats[i].add(result)
+						completed = append(completed, result)
+						continue
+					}
+
+					if ticks != nil {
+						select {
+						case <-ticks:
+						case <-done:
+						}
+					}
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					result := user.runContractUnderLoad(contract)
+					stats[i].add(result)
+					completed = append(completed, result)
+				}
+			}
+		}(runner.virtualUser())
+	}
+	wg.Wait()
+
+	result = &LoadResult{
+		Name:       runner.test.Name,
+		Users:      opts.Users,
+		Iterations: int(iterations),
+		Duration:   time.Since(start),
+	}
+	if opts.Iterations > 0 && result.Iterations > opts.Iterations {
+		result.Iterations = opts.Iterations
+	}
+	for _, s := range stats {
+		sort.Slice(s.result.Latencies, func(i, j int) bool { return s.result.Latencies[i] < s.result.Latencies[j] })
+		result.Contracts = append(result.Contracts, s.result)
+	}
+
+	return result, nil
+}
+
+// virtualUser returns a Runner sharing the settings of runner, with variables and cookies of its own.  They start as a
+// copy of the variables of runner and of the cookies its jar holds for its url, such as those set by the setup.
+func (runner *Runner) virtualUser() *Runner {
+	user := &Runner{
+		successOutput: runner.successOutput,
+		failureOutput: runner.failureOutput,
+		client:        runner.client,
+		parallelism:   1,
+		validators:    runner.validators,
+		test:          runner.test,
+		url:           runner.url,
+		globals:       runner.globals.copy(),
+		environment:   runner.environment,
+		authenticator: runner.authenticator,
+	}
+	user.applyCookieJar()
+	if runner.jar != nil && user.jar != nil {
+		if u, err := url.Parse(runner.url); err == nil {
+			user.jar.SetCookies(u, runner.jar.Cookies(u))
+		}
+	}
+	return user
+}
+
+// runContractUnderLoad runs a contract and returns its result, with the duration of its last request
+func (runner *Runner) runContractUnderLoad(contract Contract) ContractResult {
+	result := ContractResult{Name: contract.Name}
+
+	start := time.Now()
+	result.Err = runner.validateContract(contract, &result)
+	result.Duration = time.Since(start)
+	if result.Timings != nil {
+		result.Duration = result.Timings.Total
+	}
+
+	return result
+}
+
+type contractLoadStats struct {
+	mu     sync.Mutex
+	result ContractLoadResult
+}
+
+func (s *contractLoadStats) add(result ContractResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if result.Skipped() {
+		s.result.Skipped++
+		return
+	}
+
+	s.result.Requests++
+	s.result.Latencies = append(s.result.Latencies, result.Duration)
+	if result.Err != nil {
+		s.result.Failed++
+		s.result.Failures[result.Err.Error()]++
+	}
+}
+
+// Write writes a table of the throughput, error rate and latency percentiles of every contract, followed by the
+// failures and the overall result
+func (r *LoadResult) Write(out io.Writer) {
+	seconds := r.Duration.Seconds()
+	rate := func(requests int) float64 {
+		if seconds == 0 {
+			return 0
+		}
+		return float64(requests) / seconds
+	}
+
+	fmt.Fprintf(out, "%s: %d iterations by %d users in %v\n\n", r.Name, r.Iterations, r.Users, r.Duration.Round(time.Millisecond))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTRACT\tREQUESTS\tFAILED\tSKIPPED\tRPS\tMIN\tP50\tP90\tP95\tP99\tMAX")
+	for _, c := range r.Contracts {
+		failed := 0.0
+		if c.Requests > 0 {
+			failed = float64(c.Failed) / float64(c.Requests) * 100
+		}
+		min := time.Duration(0)
+		if len(c.Latencies) > 0 {
+			min = c.Latencies[0]
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%d\t%.1f\t%v\t%v\t%v\t%v\t%v\t%v\n", c.Name, c.Requests, failed, c.Skipped, rate(c.Requests),
+			roundTiming(min), roundTiming(c.Percentile(50)), roundTiming(c.Percentile(90)), roundTiming(c.Percentile(95)),
+			roundTiming(c.Percentile(99)), roundTiming(c.Percentile(100)))
+	}
+	w.Flush()
+	fmt.Fprintln(out)
+
+	for _, c := range r.Contracts {
+		messages := make([]string, 0, len(c.Failures))
+		for message := range c.Failures {
+			messages = append(messages, message)
+		}
+		// the most frequent failures first
+		sort.Slice(messages, func(i, j int) bool {
+			if c.Failures[messages[i]] != c.Failures[messages[j]] {
+				return c.Failures[messages[i]] > c.Failures[messages[j]]
+			}
+			return messages[i] < messages[j]
+		})
+		for _, message := range messages {
+			failure(out, c.Name, "%s (%d times)", message, c.Failures[message])
+		}
+	}
+
+	if failed := r.Failed(); failed > 0 {
+		red.Fprintf(out, "FAILED (%d of %d requests failed)\n", failed, r.Requests())
+		return
+	}
+	boldGreen.Fprintf(out, "OK (%d requests, %.1f requests/s)\n", r.Requests(), rate(r.Requests()))
+}
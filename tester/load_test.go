@@ -0,0 +1,212 @@
+package tester
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	var requests int64
+	var mu sync.Mutex
+	sessions := make(map[string]bool)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&requests, 1)
+		switch r.URL.Path {
+		case "/login":
+			session := fmt.Sprintf("s%d", n)
+			mu.Lock()
+			sessions[session] = true
+			mu.Unlock()
+			fmt.Fprintf(w, `{"session": %q}`, session)
+		case "/flaky":
+			if n%4 == 0 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		case "/me":
+			mu.Lock()
+			defer mu.Unlock()
+			if !sessions[r.Header.Get("X-Session")] {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+	defer server.Close()
+
+	test := &Test{
+		Name: "load",
+		Contracts: []Contract{
+			{Name: "login", Path: "/login", Outputs: map[string]string{"session": "JSON.session"}},
+			{Name: "flaky", Path: "/flaky", ExpectedHTTPCode: 200},
+			{Name: "me", Path: "/me", Headers: map[string]string{"X-Session": "::session::"}, ExpectedHTTPCode: 200},
+		},
+	}
+	assert.NoError(t, test.init())
+
+	reporter := &recordingReporter{}
+	runner := NewRunner(server.URL, test, WithReporter(reporter))
+
+	result, err := runner.Load(LoadOptions{Users: 4, Iterations: 20})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 20, result.Iterations)
+	assert.Equal(t, int64(60), atomic.LoadInt64(&requests))
+	assert.Equal(t, 60, result.Requests())
+
+	login, flaky, me := result.Contracts[0], result.Contracts[1], result.Contracts[2]
+	assert.Equal(t, 20, login.Requests)
+	assert.Equal(t, 0, login.Failed)
+	assert.Len(t, login.Latencies, 20)
+	assert.True(t, login.Percentile(50) <= login.Percentile(99))
+
+	// one request in four fails, and is reported with the message of a normal run
+	assert.Equal(t, 20, flaky.Requests)
+	assert.True(t, flaky.Failed > 0)
+	assert.Equal(t, map[string]int{"expected http response code 200 got 503": flaky.Failed}, flaky.Failures)
+	assert.Equal(t, flaky.Failed, result.Failed())
+
+	// every user keeps the session of its own login
+	assert.Equal(t, 0, me.Failed)
+
+	// the reporters are not called under load
+	assert.Empty(t, reporter.events)
+
+	// the variables of the users are not visible to the runner
+	_, ok := runner.globals.get("session")
+	assert.False(t, ok)
+}
+
+func TestLoadDependsOn(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		if r.URL.Path == "/login" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	test := &Test{
+		Contracts: []Contract{
+			{Name: "login", Path: "/login", ExpectedHTTPCode: 200},
+			{Name: "me", Path: "/me", DependsOn: []string{"login"}},
+		},
+	}
+	assert.NoError(t, test.init())
+
+	result, err := NewRunner(server.URL, test).Load(LoadOptions{Users: 2, Iterations: 6})
+	assert.NoError(t, err)
+
+	// the contract is never sent since login always fails
+	assert.Equal(t, int64(6), atomic.LoadInt64(&requests))
+	assert.Equal(t, 6, result.Contracts[0].Failed)
+	assert.Equal(t, 0, result.Contracts[1].Requests)
+	assert.Equal(t, 6, result.Contracts[1].Skipped)
+}
+
+func TestLoadSetupCookies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		case "/me":
+			if cookie, err := r.Cookie("session"); err != nil || cookie.Value != "abc" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		}
+	}))
+	defer server.Close()
+
+	test := &Test{
+		CookieJar: true,
+		Setup:     []Contract{{Name: "login", Path: "/login", ExpectedHTTPCode: 200}},
+		Contracts: []Contract{{Name: "me", Path: "/me", ExpectedHTTPCode: 200}},
+	}
+	assert.NoError(t, test.init())
+
+	result, err := NewRunner(server.URL, test).Load(LoadOptions{Users: 3, Iterations: 6})
+	assert.NoError(t, err)
+
+	// every user starts with the cookies set by the setup
+	assert.Equal(t, 6, result.Contracts[0].Requests)
+	assert.Equal(t, 0, result.Contracts[0].Failed)
+}
+
+func TestLoadDurationAndRate(t *testing.T) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+	}))
+	defer server.Close()
+
+	test := &Test{Contracts: []Contract{{Name: "get", Path: "/"}}}
+
+	start := time.Now()
+	result, err := NewRunner(server.URL, test).Load(LoadOptions{Users: 5, Duration: 500 * time.Millisecond, RPS: 20})
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 500*time.Millisecond)
+
+	// about 10 requests in 500ms at 20 requests per second
+	assert.True(t, requests >= 5 && requests <= 12, "%d requests", requests)
+	assert.Equal(t, int(requests), result.Requests())
+}
+
+func TestLoadOptions(t *testing.T) {
+	runner := NewRunner("http://localhost", &Test{})
+
+	_, err := runner.Load(LoadOptions{Iterations: 1})
+	assert.EqualError(t, err, "expected at least 1 user, got 0")
+
+	_, err = runner.Load(LoadOptions{Users: 1})
+	assert.EqualError(t, err, "expected a duration or a number of iterations")
+
+	_, err = runner.Load(LoadOptions{Users: 1, Iterations: 1, RPS: -1})
+	assert.EqualError(t, err, "expected a positive number of requests per second, got -1")
+}
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	result := ContractLoadResult{Latencies: latencies}
+
+	assert.Equal(t, 50*time.Millisecond, result.Percentile(50))
+	assert.Equal(t, 99*time.Millisecond, result.Percentile(99))
+	assert.Equal(t, 100*time.Millisecond, result.Percentile(100))
+	assert.Equal(t, time.Millisecond, result.Percentile(0))
+	assert.Equal(t, time.Duration(0), ContractLoadResult{}.Percentile(50))
+}
+
+func TestLoadResultWrite(t *testing.T) {
+	result := &LoadResult{
+		Name:       "suite",
+		Users:      2,
+		Iterations: 4,
+		Duration:   2 * time.Second,
+		Contracts: []ContractLoadResult{
+			{Name: "fast", Requests: 4, Latencies: []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 4 * time.Millisecond}},
+			{Name: "broken", Requests: 4, Failed: 3, Failures: map[string]int{"timeout": 1, "expected http response code 200 got 500": 2},
+				Latencies: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 40 * time.Millisecond}},
+		},
+	}
+
+	var out bytes.Buffer
+	result.Write(&out)
+
+	assert.Equal(t, "suite: 4 iterations by 2 users in 2s\n\n"+
+		"CONTRACT  REQUESTS  FAILED  SKIPPED  RPS  MIN   P50   P90   P95   P99   MAX\n"+
+		"fast      4         0.0%    0        2.0  1ms   2ms   4ms   4ms   4ms   4ms\n"+
+		"broken    4         75.0%   0        2.0  10ms  20ms  40ms  40ms  40ms  40ms\n\n"+
+		bad+"\tbroken: expected http response code 200 got 500 (2 times)\n"+
+		bad+"\tbroken: timeout (1 times)\n"+
+		"FAILED (3 of 8 requests failed)\n", out.String())
+}
@@ -0,0 +1,113 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// output is a compiled value of the outputs of a contract, selecting a part of the response
+type output struct {
+	source     string
+	expression string
+
+	jsonPath *jsonPath
+	regex    *regexp.Regexp
+	xpath    *xpath
+}
+
+// compileOutput compiles an output in the form SOURCE.expression, or status
+func compileOutput(value string) (output, error) {
+	parts := strings.SplitN(value, ".", 2)
+	o := output{source: strings.ToLower(parts[0])}
+	if len(parts) > 1 {
+		o.expression = parts[1]
+	}
+
+	if o.source == "status" {
+		if len(parts) > 1 {
+			return o, fmt.Errorf("status does not take an expression, got %q", value)
+		}
+		return o, nil
+	}
+
+	if len(parts) < 2 || o.expression == "" {
+		return o, fmt.Errorf("expected SOURCE.expression or status, got %q", value)
+	}
+
+	var err error
+	switch o.source {
+	case "json":
+		o.jsonPath, err = compileJSONPath(o.expression)
+	case "header", "cookie":
+	case "regex":
+		o.regex, err = regexp.Compile(o.expression)
+		if err != nil {
+			err = errors.Wrap(err, "invalid regular expression")
+		}
+	case "xpath":
+		o.xpath, err = compileXPath(o.expression)
+	default:
+		err = fmt.Errorf("unknown source %s in %q, expected json, header, status, cookie, regex or xpath", parts[0], value)
+	}
+
+	return o, err
+}
+
+// compileOutputs compiles the outputs of a contract, by variable name
+func compileOutputs(outputs map[string]string) (map[string]output, error) {
+	compiled := make(map[string]output, len(outputs))
+	for key, value := range outputs {
+		o, err := compileOutput(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid output %v", key)
+		}
+		compiled[key] = o
+	}
+	return compiled, nil
+}
+
+// evaluate returns the part of the response selected by the output
+func (o output) evaluate(resp *http.Response, body []byte) (string, error) {
+	switch o.source {
+	case "json":
+		return evaluateJSONPath(o.jsonPath, body)
+
+	case "status":
+		if resp == nil {
+			return "", fmt.Errorf("no response")
+		}
+		return strconv.Itoa(resp.StatusCode), nil
+
+	case "header":
+		if resp != nil {
+			if values, ok := resp.Header[http.CanonicalHeaderKey(o.expression)]; ok && len(values) > 0 {
+				return values[0], nil
+			}
+		}
+		return "", fmt.Errorf("header %s not found in the response", o.expression)
+
+	case "cookie":
+		return responseCookie(resp, o.expression)
+
+	case "regex":
+		match := o.regex.FindSubmatch(body)
+		if match == nil {
+			return "", fmt.Errorf("regular expression %s did not find any matches in the response body", o.regex)
+		}
+		// the first capture group, or the whole match when there is none
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+
+	case "xpath":
+		return parseXML(o.xpath, body)
+	}
+
+	return "", fmt.Errorf("unknown source %s", o.source)
+}
@@ -0,0 +1,85 @@
+package tester
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileOutput(t *testing.T) {
+	tests := []struct {
+		description string
+		value       string
+		err         string
+	}{
+		{description: "json", value: "JSON.a.b"},
+		{description: "header", value: "header.Location"},
+		{description: "status", value: "status"},
+		{description: "cookie", value: "cookie.session"},
+		{description: "regex", value: `regex.id=(\d+)`},
+		{description: "xpath", value: "xpath.//item/@id"},
+		{description: "no source", value: "token", err: `expected SOURCE.expression or status, got "token"`},
+		{description: "no expression", value: "header.", err: `expected SOURCE.expression or status, got "header."`},
+		{description: "status with expression", value: "status.code", err: `status does not take an expression, got "status.code"`},
+		{description: "unknown source", value: "body.token", err: `unknown source body in "body.token", expected json, header, status, cookie, regex or xpath`},
+		{description: "invalid json path", value: "JSON.a[", err: `json path "a[": at "[": unclosed bracket`},
+		{description: "invalid regex", value: "regex.(", err: "invalid regular expression: error parsing regexp: missing closing ): `(`"},
+	}
+
+	for _, test := range tests {
+		_, err := compileOutput(test.value)
+		if test.err == "" {
+			assert.NoError(t, err, test.description)
+		} else {
+			assert.EqualError(t, err, test.err, test.description)
+		}
+	}
+}
+
+func TestOutputEvaluate(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusCreated,
+		Header: http.Header{
+			"Location":   {"/users/42"},
+			"Set-Cookie": {"session=abc; Path=/"},
+		},
+	}
+	body := []byte(`<users><user id="42"><name>ann</name></user></users>`)
+
+	tests := []struct {
+		description string
+		value       string
+		expected    string
+		err         string
+	}{
+		{description: "header", value: "header.location", expected: "/users/42"},
+		{description: "missing header", value: "header.X-Id", err: "header X-Id not found in the response"},
+		{description: "status", value: "STATUS", expected: "201"},
+		{description: "cookie", value: "cookie.session", expected: "abc"},
+		{description: "regex capture group", value: `regex.id="(\d+)"`, expected: "42"},
+		{description: "regex without capture group", value: `regex.<name>\w+`, expected: "<name>ann"},
+		{description: "regex without match", value: `regex.id=(\d+)`, err: `regular expression id=(\d+) did not find any matches in the response body`},
+		{description: "xpath", value: "xpath./users/user[@id='42']/name", expected: "ann"},
+	}
+
+	for _, test := range tests {
+		o, err := compileOutput(test.value)
+		if !assert.NoError(t, err, test.description) {
+			continue
+		}
+
+		value, err := o.evaluate(resp, body)
+		if test.err == "" {
+			assert.NoError(t, err, test.description)
+			assert.Equal(t, test.expected, value, test.description)
+		} else {
+			assert.EqualError(t, err, test.err, test.description)
+		}
+	}
+}
+
+func TestOutputsAreCheckedWhenLoading(t *testing.T) {
+	test := &Test{Contracts: []Contract{{Name: "login", Outputs: map[string]string{"token": "token"}}}}
+	assert.EqualError(t, test.init(), `contract login: outputs: invalid output token: expected SOURCE.expression or status, got "token"`)
+}
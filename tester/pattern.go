@@ -0,0 +1,44 @@
+package tester
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pattern is an expected value written in a test file: a regular expression when it begins by "r/", and a literal
+// string otherwise.  A literal string beginning by "r/" is written with a leading backslash, as in `\r/`, and one
+// beginning by `\r/` with two.
+type pattern struct {
+	literal string
+	re      *regexp.Regexp
+}
+
+func compilePattern(s string) (pattern, error) {
+	if strings.HasPrefix(s, "r/") {
+		re, err := regexp.Compile(s[2:])
+		if err != nil {
+			return pattern{}, errors.Wrap(err, "invalid regular expression")
+		}
+		return pattern{re: re}, nil
+	}
+
+	if strings.HasPrefix(s, `\`) && strings.HasPrefix(strings.TrimLeft(s, `\`), "r/") {
+		s = s[1:]
+	}
+	return pattern{literal: s}, nil
+}
+
+// isZero returns whether the pattern is an empty literal string, which is usually not checked
+func (p pattern) isZero() bool {
+	return p.re == nil && p.literal == ""
+}
+
+// matchString returns whether s matches the regular expression, or is equal to the literal string
+func (p pattern) matchString(s string) bool {
+	if p.re != nil {
+		return p.re.MatchString(s)
+	}
+	return s == p.literal
+}
@@ -0,0 +1,107 @@
+package tester
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompilePattern(t *testing.T) {
+	tests := []struct {
+		description string
+		value       string
+		matches     []string
+		mismatches  []string
+		err         string
+	}{
+		{description: "literal", value: "abc", matches: []string{"abc"}, mismatches: []string{"abcd"}},
+		{description: "regular expression", value: `r/^\d+$`, matches: []string{"123"}, mismatches: []string{"r/^\\d+$", "12a"}},
+		{description: "escaped literal", value: `\r/[1-a{5}`, matches: []string{"r/[1-a{5}"}, mismatches: []string{`\r/[1-a{5}`}},
+		{description: "escaped backslash", value: `\\r/x`, matches: []string{`\r/x`}, mismatches: []string{"r/x"}},
+		{description: "other backslashes", value: `\d`, matches: []string{`\d`}},
+		{description: "invalid regular expression", value: "r/[1-a{5}", err: "invalid regular expression: error parsing regexp: missing closing ]: `[1-a{5}`"},
+	}
+
+	for _, test := range tests {
+		p, err := compilePattern(test.value)
+		if test.err != "" {
+			assert.EqualError(t, err, test.err, test.description)
+			continue
+		}
+		assert.NoError(t, err, test.description)
+		for _, s := range test.matches {
+			assert.True(t, p.matchString(s), "%s: %s", test.description, s)
+		}
+		for _, s := range test.mismatches {
+			assert.False(t, p.matchString(s), "%s: %s", test.description, s)
+		}
+	}
+}
+
+func TestInvalidPatternsAreReportedWhenLoading(t *testing.T) {
+	test := &Test{Contracts: []Contract{
+		{Name: "body", ExpectedResponseBody: "r/[1-a{5}", ExpectedResponses: []string{"r/(", "ok"}},
+		{Name: "valid", ExpectedResponseBody: `\r/[1-a{5}`},
+		{Name: "headers", ExpectedHeaders: map[string]string{"X-Id": "r/[", "Content-Type": "r/json"}},
+	}}
+
+	assert.EqualError(t, test.init(), "contract body: response_body_contains r/(: invalid regular expression: error parsing regexp: missing closing ): `(`; "+
+		"contract body: response_body_contains r/[1-a{5}: invalid regular expression: error parsing regexp: missing closing ]: `[1-a{5}`; "+
+		"contract headers: response_headers_contain X-Id: invalid regular expression: error parsing regexp: missing closing ]: `[`")
+}
+
+func TestPatternAssertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Id", "abc")
+		w.Write([]byte(`{"foo": "r/[1-a{5}!"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		description string
+		contract    Contract
+		expected    string
+	}{
+		{
+			description: "escaped literal in the body",
+			contract:    Contract{ExpectedResponseBody: `\r/[1-a{5}`},
+		},
+		{
+			description: "regular expression in the body",
+			contract:    Contract{ExpectedResponses: []string{`r/"foo":\s*"r/`}},
+		},
+		{
+			description: "regular expression not matching the body",
+			contract:    Contract{ExpectedResponses: []string{`r/^\d+$`}},
+			expected:    "regular expression did not find any matches in the response body",
+		},
+		{
+			description: "regular expression in a header",
+			contract:    Contract{ExpectedHeaders: map[string]string{"X-Id": `r/^\w+$`}},
+		},
+		{
+			description: "regular expression not matching a header",
+			contract:    Contract{ExpectedHeaders: map[string]string{"X-Id": `r/^\d+$`}},
+			expected:    "regular expression did not find any matches in header X-Id value abc",
+		},
+		{
+			description: "invalid regular expression",
+			contract:    Contract{ExpectedResponses: []string{"r/[1-a{5}"}},
+			expected:    "response_body_contains r/[1-a{5}: invalid regular expression: error parsing regexp: missing closing ]: `[1-a{5}`",
+		},
+	}
+
+	for _, test := range tests {
+		test.contract.Name, test.contract.Method = test.description, "GET"
+		runner := NewRunner(server.URL, &Test{Contracts: []Contract{test.contract}})
+		err := runner.validateContract(runner.test.Contracts[0], &ContractResult{})
+
+		if test.expected == "" {
+			assert.NoError(t, err, test.description)
+		} else {
+			assert.EqualError(t, err, test.expected, test.description)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// phaseServer creates an item on POST /items, and records the paths of the requests it received
+type phaseServer struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (s *phaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.paths = append(s.paths, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/fail":
+		w.WriteHeader(http.StatusInternalServerError)
+	case r.Method == http.MethodPost:
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": "42"}`)
+	}
+}
+
+func TestSetupAndTeardown(t *testing.T) {
+	server := &phaseServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	test := &Test{
+		Name:     "suite",
+		Setup:    []Contract{{Name: "create", Path: "/items", Method: "POST", ExpectedHTTPCode: 201, Outputs: map[string]string{"id": "JSON.id"}}},
+		Teardown: []Contract{{Name: "delete", Path: "/items/::id::", Method: "DELETE", ExpectedHTTPCode: 200}},
+		Contracts: []Contract{
+			{Name: "get", Path: "/items/::id::", ExpectedHTTPCode: 200},
+			{Name: "fail", Path: "/fail", ExpectedHTTPCode: 200},
+		},
+	}
+	assert.NoError(t, test.init())
+
+	reporter := &recordingReporter{}
+	runner := NewRunner(ts.URL, test, WithReporter(reporter))
+	assert.False(t, runner.Run())
+	assert.False(t, runner.SetupFailed())
+
+	// the teardown runs after a failed contract, with the outputs of the setup
+	assert.Equal(t, []string{"POST /items", "GET /items/42", "GET /fail", "DELETE /items/42"}, server.paths)
+	assert.Equal(t, "suite started suite 4", reporter.events[0])
+
+	var names []string
+	for _, result := range reporter.results {
+		names = append(names, result.displayName())
+	}
+	assert.Equal(t, []string{"setup create", "get", "fail", "teardown delete"}, names)
+	assert.Equal(t, 4, reporter.suite.Total)
+	assert.Equal(t, 1, reporter.suite.Failed)
+	assert.False(t, reporter.suite.SetupFailed)
+}
+
+func TestSetupFailed(t *testing.T) {
+	server := &phaseServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	test := &Test{
+		Setup: []Contract{
+			{Name: "broken", Path: "/fail", ExpectedHTTPCode: 200},
+			{Name: "create", Path: "/items", Method: "POST", ExpectedHTTPCode: 201},
+		},
+		Contracts: []Contract{{Name: "get", Path: "/items", ExpectedHTTPCode: 200}},
+		Teardown:  []Contract{{Name: "cleanup", Path: "/items", Method: "DELETE", ExpectedHTTPCode: 200}},
+	}
+	assert.NoError(t, test.init())
+
+	reporter := &recordingReporter{}
+	runner := NewRunner(ts.URL, test, WithReporter(reporter))
+	assert.False(t, runner.Run())
+	assert.True(t, runner.SetupFailed())
+	assert.True(t, reporter.suite.SetupFailed)
+
+	// the setup stops at its first failure, and the contracts are skipped
+	assert.Equal(t, []string{"GET /fail", "DELETE /items"}, server.paths)
+	if assert.Len(t, reporter.results, 4) {
+		assert.EqualError(t, reporter.results[0].Err, "expected http response code 200 got 500")
+		assert.Equal(t, "setup", reporter.results[0].Phase)
+		assert.Equal(t, "setup contract broken failed", reporter.results[1].SkipReason)
+		assert.Equal(t, "setup contract broken failed", reporter.results[2].SkipReason)
+		assert.Equal(t, "teardown", reporter.results[3].Phase)
+		assert.False(t, reporter.results[3].Skipped())
+	}
+	assert.Equal(t, 1, reporter.suite.Failed)
+	assert.Equal(t, 2, reporter.suite.Skipped)
+}
+
+func TestInvalidSetupContract(t *testing.T) {
+	test := &Test{
+		Setup:    []Contract{{Name: "create", Outputs: map[string]string{"id": "body.id"}}},
+		Teardown: []Contract{{Name: "delete", ExpectedResponseBody: "r/("}},
+	}
+	err := test.init()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "setup contract create: ")
+		assert.Contains(t, err.Error(), "teardown contract delete: ")
+	}
+}
+
+func TestIncludeSetupAndTeardown(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"main.yaml": `
+include:
+- common.yaml
+templates:
+  admin:
+    method: POST
+setup:
+- name: login
+  path: /login
+  extends: admin
+contracts:
+- name: get
+  path: /items
+teardown:
+- name: logout
+  path: /logout
+  extends: admin
+`,
+		"common.yaml": `
+setup:
+- name: seed
+  path: /seed
+teardown:
+- name: clean
+  path: /clean
+`,
+	})
+
+	test, err := loadTest(filepath.Join(dir, "main.yaml"), make(map[string]bool))
+	if !assert.NoError(t, err) || !assert.NoError(t, test.init()) {
+		return
+	}
+
+	names := func(contracts []Contract) (names []string) {
+		for _, contract := range contracts {
+			names = append(names, contract.Name+" "+contract.Method)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"login POST", "seed "}, names(test.Setup))
+	assert.Equal(t, []string{"get "}, names(test.Contracts))
+	assert.Equal(t, []string{"logout POST", "clean "}, names(test.Teardown))
+}
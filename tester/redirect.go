@@ -0,0 +1,144 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// defaultMaxRedirects is the number of redirects followed with follow_redirects: true, the same as the http package
+const defaultMaxRedirects = 10
+
+// FollowRedirects is the maximum number of redirects followed for a contract.  It is written in test files as true,
+// false or a number.
+type FollowRedirects int
+
+func parseFollowRedirects(v interface{}) (FollowRedirects, error) {
+	switch val := v.(type) {
+	case bool:
+		if val {
+			return defaultMaxRedirects, nil
+		}
+		return 0, nil
+	case int:
+		if val >= 0 {
+			return FollowRedirects(val), nil
+		}
+	case float64:
+		if val >= 0 && val == float64(int(val)) {
+			return FollowRedirects(val), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid follow_redirects %v: expected true, false or a number of redirects", v)
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (f *FollowRedirects) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+	parsed, err := parseFollowRedirects(v)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (f *FollowRedirects) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parsed, err := parseFollowRedirects(v)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
+
+// Redirect is a redirect response received while following the redirects of a contract
+type Redirect struct {
+	StatusCode int `json:"status,omitempty" yaml:"status,omitempty"`
+	// Location is the Location header of the redirect.  In a redirect_chain assertion, it can be a regular
+	// expression beginning by "r/".
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+
+	location pattern
+}
+
+// initRedirectChain compiles the locations of a redirect_chain assertion
+func initRedirectChain(chain []Redirect) error {
+	for i := range chain {
+		location, err := compilePattern(chain[i].Location)
+		if err != nil {
+			return errors.Wrapf(err, "redirect %d", i+1)
+		}
+		chain[i].location = location
+	}
+	return nil
+}
+
+// withRedirects returns a copy of client which follows up to max redirects, then returns the last response
+func withRedirects(client *http.Client, max FollowRedirects) *http.Client {
+	c := *client
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > int(max) {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+	return &c
+}
+
+// redirectChain returns the redirects followed to obtain resp, in order
+func redirectChain(resp *http.Response) []Redirect {
+	var chain []Redirect
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append([]Redirect{{
+			StatusCode: req.Response.StatusCode,
+			Location:   req.Response.Header.Get("Location"),
+		}}, chain...)
+	}
+	return chain
+}
+
+func validateRedirectChain(contract Contract, resp *http.Response) error {
+	chain := redirectChain(resp)
+
+	format := func(chain []Redirect) string {
+		hops := make([]string, len(chain))
+		for i, r := range chain {
+			hops[i] = fmt.Sprintf("%d %s", r.StatusCode, r.Location)
+		}
+		return "[" + strings.Join(hops, ", ") + "]"
+	}
+
+	if len(chain) != len(contract.RedirectChain) {
+		return fmt.Errorf("expected %d redirects, got %d: %s", len(contract.RedirectChain), len(chain), format(chain))
+	}
+
+	for i, expected := range contract.RedirectChain {
+		actual := chain[i]
+		if expected.StatusCode != 0 && expected.StatusCode != actual.StatusCode {
+			return fmt.Errorf("redirect %d: expected http response code %d got %d", i+1, expected.StatusCode, actual.StatusCode)
+		}
+
+		switch {
+		case expected.location.re != nil:
+			if !expected.location.matchString(actual.Location) {
+				return fmt.Errorf("redirect %d: regular expression did not find any matches in location %s", i+1, actual.Location)
+			}
+		case !expected.location.isZero() && !expected.location.matchString(actual.Location):
+			return fmt.Errorf("redirect %d: expected location %s got %s", i+1, expected.location.literal, actual.Location)
+		}
+	}
+
+	return nil
+}
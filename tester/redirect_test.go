@@ -0,0 +1,139 @@
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.Redirect(w, r, "/step?session=1", http.StatusFound)
+		case "/step":
+			http.Redirect(w, r, "/home", http.StatusSeeOther)
+		default:
+			fmt.Fprint(w, "welcome")
+		}
+	}))
+	defer server.Close()
+
+	follow := func(n FollowRedirects) *FollowRedirects { return &n }
+
+	tests := []struct {
+		description string
+		contract    Contract
+		expected    string
+	}{
+		{
+			description: "redirects not followed by default",
+			contract:    Contract{ExpectedHTTPCode: 302},
+		},
+		{
+			description: "all redirects followed",
+			contract: Contract{
+				FollowRedirects:   follow(defaultMaxRedirects),
+				ExpectedHTTPCode:  200,
+				ExpectedResponses: []string{"welcome"},
+				RedirectChain: []Redirect{
+					{StatusCode: 302, Location: "/step?session=1"},
+					{StatusCode: 303, Location: "r/^/home$"},
+				},
+			},
+		},
+		{
+			description: "limited number of redirects",
+			contract: Contract{
+				FollowRedirects:  follow(1),
+				ExpectedHTTPCode: 303,
+				RedirectChain:    []Redirect{{StatusCode: 302}},
+			},
+		},
+		{
+			description: "redirects disabled",
+			contract:    Contract{FollowRedirects: follow(0), ExpectedHTTPCode: 302},
+		},
+		{
+			description: "wrong number of redirects",
+			contract: Contract{
+				FollowRedirects: follow(defaultMaxRedirects),
+				RedirectChain:   []Redirect{{StatusCode: 302}},
+			},
+			expected: "expected 1 redirects, got 2: [302 /step?session=1, 303 /home]",
+		},
+		{
+			description: "wrong status",
+			contract: Contract{
+				FollowRedirects: follow(defaultMaxRedirects),
+				RedirectChain:   []Redirect{{StatusCode: 301}, {}},
+			},
+			expected: "redirect 1: expected http response code 301 got 302",
+		},
+		{
+			description: "wrong location",
+			contract: Contract{
+				FollowRedirects: follow(defaultMaxRedirects),
+				RedirectChain:   []Redirect{{}, {Location: "/dashboard"}},
+			},
+			expected: "redirect 2: expected location /dashboard got /home",
+		},
+	}
+
+	for _, test := range tests {
+		contract := test.contract
+		contract.Name, contract.Path, contract.Method = test.description, "/login", "GET"
+
+		suite := &Test{Contracts: []Contract{contract}}
+		if !assert.NoError(t, suite.init(), test.description) {
+			continue
+		}
+
+		client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		err := NewRunner(server.URL, suite, WithHTTPClient(client)).validateContract(suite.Contracts[0], &ContractResult{})
+
+		if test.expected == "" {
+			assert.NoError(t, err, test.description)
+		} else {
+			assert.EqualError(t, err, test.expected, test.description)
+		}
+	}
+}
+
+func TestUnmarshalFollowRedirects(t *testing.T) {
+	tests := []struct {
+		description string
+		data        string
+		expected    FollowRedirects
+		err         bool
+	}{
+		{description: "true", data: "true", expected: defaultMaxRedirects},
+		{description: "false", data: "false", expected: 0},
+		{description: "number", data: "3", expected: 3},
+		{description: "negative", data: "-1", err: true},
+		{description: "string", data: `"yes"`, err: true},
+	}
+
+	for _, test := range tests {
+		var fromYAML, fromJSON FollowRedirects
+		yamlErr := yaml.Unmarshal([]byte(test.data), &fromYAML)
+		jsonErr := json.Unmarshal([]byte(test.data), &fromJSON)
+
+		if test.err {
+			assert.Error(t, yamlErr, test.description)
+			assert.Error(t, jsonErr, test.description)
+			continue
+		}
+		assert.NoError(t, yamlErr, test.description)
+		assert.NoError(t, jsonErr, test.description)
+		assert.Equal(t, test.expected, fromYAML, test.description)
+		assert.Equal(t, test.expected, fromJSON, test.description)
+	}
+}
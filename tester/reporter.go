@@ -0,0 +1,159 @@
+package tester
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+const (
+	good = "\u2713"
+	bad  = "\u2717"
+	skip = "\u2298"
+)
+
+var (
+	red       = color.New(color.FgRed, color.Bold)
+	green     = color.New(color.FgGreen)
+	yellow    = color.New(color.FgYellow)
+	boldGreen = color.New(color.FgGreen, color.Bold)
+)
+
+// Request holds the details of the http request sent for a contract, after variables have been replaced
+type Request struct {
+	Method  string
+	URL     string
+	Headers http.Header
+	Body    string
+}
+
+// Response holds the details of the http response received for a contract
+type Response struct {
+	StatusCode int
+	Headers    http.Header
+	Body       []byte
+}
+
+// ContractResult holds the outcome of running a single contract
+type ContractResult struct {
+	Name string
+	// Phase is setup or teardown for the setup and teardown contracts, and empty for the other contracts
+	Phase string
+
+	// Request is nil if the contract failed before its request could be created
+	Request *Request
+	// Response is nil if the contract failed before a response was received
+	Response *Response
+
+	// Attempts is the number of times the request was sent, more than 1 when the contract was retried
+	Attempts int
+
+	// Timings are the timings of the last request sent, nil if no response was received
+	Timings *Timings
+
+	Duration time.Duration
+	Err      error
+
+	// SkipReason is why the contract was not run, empty when it was run
+	SkipReason string
+}
+
+// Skipped returns whether the contract was not run, because a contract it depends on did not pass or an earlier
+// contract failed
+func (r ContractResult) Skipped() bool {
+	return r.SkipReason != ""
+}
+
+// SuiteResult holds the outcome of running a full Test
+type SuiteResult struct {
+	Name      string
+	Total     int
+	Failed    int
+	Skipped   int
+	Duration  time.Duration
+	Contracts []ContractResult
+	// SetupFailed is set when a setup contract failed, in which case the other contracts were not run
+	SetupFailed bool
+}
+
+// Passed returns the number of contracts which were run and passed
+func (r SuiteResult) Passed() int {
+	return r.Total - r.Failed - r.Skipped
+}
+
+// Reporter receives the results of a Runner as the test suite progresses.
+// Calls to a Reporter are never made concurrently by a single Runner, but when contracts run in parallel
+// several contracts may be started before the first of them is finished.  Skipped contracts are not started, and only
+// given to ContractFinished.
+type Reporter interface {
+	SuiteStarted(name string, contracts int)
+	ContractStarted(contract Contract)
+	ContractFinished(result ContractResult)
+	SuiteFinished(result SuiteResult)
+}
+
+// terminalReporter writes colored results to the success and failure outputs
+type terminalReporter struct {
+	successOutput io.Writer
+	failureOutput io.Writer
+}
+
+func (r *terminalReporter) SuiteStarted(name string, contracts int) {}
+
+func (r *terminalReporter) ContractStarted(contract Contract) {}
+
+func (r *terminalReporter) ContractFinished(result ContractResult) {
+	name := result.displayName()
+	if result.Attempts > 1 {
+		name = fmt.Sprintf("%s (%d attempts)", name, result.Attempts)
+	}
+
+	if result.Skipped() {
+		skipped(r.failureOutput, name, result.SkipReason)
+		return
+	}
+	if result.Err != nil {
+		failure(r.failureOutput, name, result.Err.Error())
+		return
+	}
+	if result.Timings != nil {
+		name = fmt.Sprintf("%s (%v)", name, roundTiming(result.Timings.Total))
+	}
+	success(r.successOutput, name)
+}
+
+func (r *terminalReporter) SuiteFinished(result SuiteResult) {
+	if result.SetupFailed {
+		red.Fprintf(r.failureOutput, "FAILED (setup failed, tests not run)\n")
+		return
+	}
+	if result.Failed > 0 {
+		red.Fprintf(r.failureOutput, "FAILED (%d passed, %d failed, %d skipped)\n", result.Passed(), result.Failed, result.Skipped)
+		return
+	}
+
+	boldGreen.Fprintf(r.successOutput, "OK (%d passed, %d skipped)\n", result.Passed(), result.Skipped)
+}
+
+// displayName returns the name of the contract, preceded by its phase
+func (r ContractResult) displayName() string {
+	if r.Phase == "" {
+		return r.Name
+	}
+	return r.Phase + " " + r.Name
+}
+
+func success(out io.Writer, name string) {
+	green.Fprintf(out, "%v\t%s\n", good, name)
+}
+
+func failure(out io.Writer, name, format string, args ...interface{}) {
+	red.Fprintf(out, "%v\t%s: %s\n", bad, name, fmt.Sprintf(format, args...))
+}
+
+func skipped(out io.Writer, name, reason string) {
+	yellow.Fprintf(out, "%v\t%s: SKIPPED, %s\n", skip, name, reason)
+}
@@ -0,0 +1,106 @@
+package tester
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingReporter struct {
+	events  []string
+	results []ContractResult
+	suite   SuiteResult
+}
+
+func (r *recordingReporter) SuiteStarted(name string, contracts int) {
+	r.events = append(r.events, fmt.Sprintf("suite started %s %d", name, contracts))
+}
+
+func (r *recordingReporter) ContractStarted(contract Contract) {
+	r.events = append(r.events, "contract started "+contract.Name)
+}
+
+func (r *recordingReporter) ContractFinished(result ContractResult) {
+	r.events = append(r.events, "contract finished "+result.Name)
+	r.results = append(r.results, result)
+}
+
+func (r *recordingReporter) SuiteFinished(result SuiteResult) {
+	r.events = append(r.events, "suite finished "+result.Name)
+	r.suite = result
+}
+
+func TestRunnerReporter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprint(w, "hello ", r.Header.Get("X-Name"))
+	}))
+	defer server.Close()
+
+	test := &Test{
+		Name:    "suite",
+		Globals: map[string]string{"name": "world"},
+		Contracts: []Contract{
+			{Name: "found", Path: "/found", Method: "get", Headers: map[string]string{"X-Name": "::name::"}, ExpectedHTTPCode: 200},
+			{Name: "missing", Path: "/missing", Method: "GET", ExpectedHTTPCode: 200},
+		},
+	}
+
+	reporter := &recordingReporter{}
+	ok := NewRunner(server.URL, test, WithReporter(reporter)).Run()
+
+	assert.False(t, ok)
+	assert.Equal(t, []string{
+		"suite started suite 2",
+		"contract started found",
+		"contract finished found",
+		"contract started missing",
+		"contract finished missing",
+		"suite finished suite",
+	}, reporter.events)
+
+	found := reporter.results[0]
+	assert.NoError(t, found.Err)
+	assert.Equal(t, "GET", found.Request.Method)
+	assert.Equal(t, server.URL+"/found", found.Request.URL)
+	assert.Equal(t, "world", found.Request.Headers.Get("X-Name"))
+	assert.Equal(t, http.StatusOK, found.Response.StatusCode)
+	assert.Equal(t, "/found", found.Response.Headers.Get("X-Path"))
+	assert.Equal(t, "hello world", string(found.Response.Body))
+
+	missing := reporter.results[1]
+	assert.EqualError(t, missing.Err, "expected http response code 200 got 404")
+	assert.Equal(t, http.StatusNotFound, missing.Response.StatusCode)
+
+	assert.Equal(t, 2, reporter.suite.Total)
+	assert.Equal(t, 1, reporter.suite.Failed)
+}
+
+func TestTerminalReporterSkipped(t *testing.T) {
+	var successOutput, failureOutput bytes.Buffer
+	reporter := &terminalReporter{successOutput: &successOutput, failureOutput: &failureOutput}
+
+	reporter.ContractFinished(ContractResult{Name: "profile", SkipReason: "depends on login, which failed"})
+	reporter.SuiteFinished(SuiteResult{Total: 3, Failed: 1, Skipped: 1})
+
+	assert.Equal(t, skip+"\tprofile: SKIPPED, depends on login, which failed\n"+
+		"FAILED (1 passed, 1 failed, 1 skipped)\n", failureOutput.String())
+	assert.Empty(t, successOutput.String())
+}
+
+func TestTerminalReporterSkippedWithoutFailure(t *testing.T) {
+	var successOutput, failureOutput bytes.Buffer
+	reporter := &terminalReporter{successOutput: &successOutput, failureOutput: &failureOutput}
+
+	reporter.SuiteFinished(SuiteResult{Total: 3, Skipped: 1})
+
+	assert.Equal(t, "OK (2 passed, 1 skipped)\n", successOutput.String())
+	assert.Empty(t, failureOutput.String())
+}
@@ -0,0 +1,116 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Retry defines how a contract is attempted again when it fails
+type Retry struct {
+	// Attempts is the maximum number of times the request is sent
+	Attempts int `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	// Interval is the time to wait before the second attempt
+	Interval Duration `json:"interval,omitempty" yaml:"interval,omitempty"`
+	// Backoff multiplies the interval after each attempt.  Default is 1.
+	Backoff float64 `json:"backoff,omitempty" yaml:"backoff,omitempty"`
+	// Until, when set, is the condition on which retrying stops.  The assertions of the contract are then checked
+	// once, on the response which met the condition.  When not set, the contract is retried until all its
+	// assertions pass.
+	Until *RetryCondition `json:"until,omitempty" yaml:"until,omitempty"`
+}
+
+// RetryCondition is the condition a response must meet to stop retrying a contract
+type RetryCondition struct {
+	ExpectedHTTPCode int                    `json:"http_code_is,omitempty" yaml:"http_code_is,omitempty"`
+	JSONBodyMatches  map[string]interface{} `json:"json_body_matches,omitempty" yaml:"json_body_matches,omitempty"`
+
+	jsonMatchers []jsonMatcher
+}
+
+func (r *Retry) init() error {
+	if r.Attempts < 0 {
+		return fmt.Errorf("attempts must be positive, got %d", r.Attempts)
+	}
+	if r.Interval < 0 {
+		return fmt.Errorf("interval must be positive, got %v", r.Interval)
+	}
+	if r.Backoff < 0 {
+		return fmt.Errorf("backoff must be positive, got %v", r.Backoff)
+	}
+
+	if r.Until == nil {
+		return nil
+	}
+	if r.Until.ExpectedHTTPCode == 0 && len(r.Until.JSONBodyMatches) == 0 {
+		return fmt.Errorf("until must have at least one of http_code_is or json_body_matches")
+	}
+	if len(r.Until.JSONBodyMatches) > 0 {
+		matchers, err := compileJSONMatchers(r.Until.JSONBodyMatches)
+		if err != nil {
+			return errors.Wrap(err, "until: json_body_matches")
+		}
+		r.Until.jsonMatchers = matchers
+	}
+
+	return nil
+}
+
+// check returns an error if the response does not meet the condition
+func (c *RetryCondition) check(resp *http.Response, body []byte) error {
+	contract := Contract{
+		ExpectedHTTPCode: c.ExpectedHTTPCode,
+		JSONBodyMatches:  c.JSONBodyMatches,
+		jsonMatchers:     c.jsonMatchers,
+	}
+
+	if err := validateHTTPCode(contract, resp); err != nil {
+		return err
+	}
+
+	if len(contract.JSONBodyMatches) > 0 {
+		return validateJSONBody(contract, body)
+	}
+
+	return nil
+}
+
+// retryContract calls attempt until it is done, or the attempts defined by retry are exhausted.  It returns the
+// error of the last attempt.
+func retryContract(retry *Retry, result *ContractResult, attempt func() (done bool, err error)) error {
+	attempts := 1
+	var interval time.Duration
+	backoff := 1.0
+	if retry != nil {
+		if retry.Attempts > 1 {
+			attempts = retry.Attempts
+		}
+		interval = time.Duration(retry.Interval)
+		if retry.Backoff > 0 {
+			backoff = retry.Backoff
+		}
+	}
+
+	var err error
+	for i := 1; i <= attempts; i++ {
+		result.Attempts = i
+
+		var done bool
+		if done, err = attempt(); done {
+			return err
+		}
+
+		if i < attempts {
+			time.Sleep(interval)
+			interval = time.Duration(float64(interval) * backoff)
+		}
+	}
+
+	if retry != nil && retry.Until != nil {
+		return errors.Wrap(err, "retry condition not met")
+	}
+
+	return err
+}
@@ -0,0 +1,123 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+// eventuallyConsistentServer returns 404 for the first failures requests, then a job which is pending for the next
+// pending requests, then done
+func eventuallyConsistentServer(failures, pending int32) (*httptest.Server, *int32) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		switch {
+		case n <= failures:
+			w.WriteHeader(http.StatusNotFound)
+		case n <= failures+pending:
+			fmt.Fprint(w, `{"status": "pending"}`)
+		default:
+			fmt.Fprint(w, `{"status": "done"}`)
+		}
+	}))
+	return server, &count
+}
+
+func runRetryContract(t *testing.T, url string, retry string) ContractResult {
+	contract := Contract{
+		Name:             "job",
+		Path:             "/job",
+		Method:           "GET",
+		ExpectedHTTPCode: 200,
+		JSONBodyMatches:  map[string]interface{}{"status": "done"},
+	}
+	if retry != "" {
+		assert.NoError(t, yaml.Unmarshal([]byte(retry), &contract.Retry))
+		assert.NoError(t, contract.Retry.init())
+	}
+
+	reporter := &recordingReporter{}
+	NewRunner(url, &Test{Contracts: []Contract{contract}}, WithReporter(reporter)).Run()
+
+	return reporter.results[0]
+}
+
+func TestRetryUntilPass(t *testing.T) {
+	server, count := eventuallyConsistentServer(1, 1)
+	defer server.Close()
+
+	result := runRetryContract(t, server.URL, `{attempts: 5, interval: 1ms, backoff: 2}`)
+
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 3, result.Attempts)
+	assert.Equal(t, int32(3), atomic.LoadInt32(count))
+}
+
+func TestRetryExhausted(t *testing.T) {
+	server, _ := eventuallyConsistentServer(10, 0)
+	defer server.Close()
+
+	result := runRetryContract(t, server.URL, `{attempts: 3, interval: 1ms}`)
+
+	assert.EqualError(t, result.Err, "expected http response code 200 got 404", "the last failure should be reported")
+	assert.Equal(t, 3, result.Attempts)
+}
+
+func TestRetryUntilCondition(t *testing.T) {
+	server, count := eventuallyConsistentServer(2, 1)
+	defer server.Close()
+
+	result := runRetryContract(t, server.URL, `{attempts: 5, interval: 1ms, until: {http_code_is: 200}}`)
+
+	assert.EqualError(t, result.Err, `json path "status": expected "done", got "pending"`,
+		"assertions should be checked once the condition is met, without retrying")
+	assert.Equal(t, 3, result.Attempts)
+	assert.Equal(t, int32(3), atomic.LoadInt32(count))
+}
+
+func TestRetryUntilConditionNotMet(t *testing.T) {
+	server, _ := eventuallyConsistentServer(10, 0)
+	defer server.Close()
+
+	result := runRetryContract(t, server.URL, `{attempts: 2, until: {http_code_is: 200}}`)
+
+	assert.EqualError(t, result.Err, "retry condition not met: expected http response code 200 got 404")
+	assert.Equal(t, 2, result.Attempts)
+}
+
+func TestRetryDefault(t *testing.T) {
+	server, _ := eventuallyConsistentServer(1, 0)
+	defer server.Close()
+
+	test := &Test{
+		Retry:     &Retry{Attempts: 2, Interval: Duration(time.Millisecond)},
+		Contracts: []Contract{{Name: "job", Path: "/job", Method: "GET", ExpectedHTTPCode: 200}},
+	}
+	reporter := &recordingReporter{}
+
+	assert.True(t, NewRunner(server.URL, test, WithReporter(reporter)).Run())
+	assert.Equal(t, 2, reporter.results[0].Attempts)
+}
+
+func TestRetryInit(t *testing.T) {
+	for _, retry := range []string{
+		`{attempts: -1}`,
+		`{backoff: -2}`,
+		`{until: {}}`,
+		`{until: {json_body_matches: {"a[": 1}}}`,
+	} {
+		var r Retry
+		assert.NoError(t, yaml.Unmarshal([]byte(retry), &r))
+		assert.Error(t, r.init(), "%s should be invalid", retry)
+	}
+
+	var r Retry
+	assert.Error(t, yaml.Unmarshal([]byte(`{interval: 5}`), &r), "durations without a unit should be invalid")
+}
@@ -0,0 +1,75 @@
+package tester
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/bluehoodie/smoke/internal/jsonschema"
+	"github.com/pkg/errors"
+)
+
+// loadSchema compiles the response_schema of a contract: either the path of a JSON or YAML schema file, relative to
+// dir, or an inline schema
+func loadSchema(schema interface{}, dir string) (*jsonschema.Schema, error) {
+	switch s := schema.(type) {
+	case string:
+		file := s
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read schema file %v", file)
+		}
+
+		var raw interface{}
+		if err := unmarshalInputFile(file, data, &raw); err != nil {
+			return nil, errors.Wrapf(err, "could not unmarshal schema file %v", file)
+		}
+
+		compiled, err := jsonschema.New(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid schema in %v", file)
+		}
+		return compiled, nil
+
+	case map[interface{}]interface{}, map[string]interface{}, bool:
+		compiled, err := jsonschema.New(s)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid schema")
+		}
+		return compiled, nil
+	}
+
+	return nil, fmt.Errorf("expected the path of a schema file or an inline schema, got %v", schema)
+}
+
+func validateSchema(contract Contract, body []byte) error {
+	schema := contract.schema
+	if schema == nil {
+		var err error
+		if schema, err = loadSchema(contract.ResponseSchema, ""); err != nil {
+			return err
+		}
+	}
+
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return errors.Wrap(err, "could not parse response body as json")
+	}
+
+	violations := schema.Validate(doc)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		messages[i] = v.Error()
+	}
+
+	return fmt.Errorf("response body does not match schema: %s", strings.Join(messages, "; "))
+}
@@ -0,0 +1,74 @@
+package tester
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const schemaTestFile = `
+contracts:
+- name: from_file
+  path: /users/1
+  method: GET
+  response_schema: schemas/user.json
+
+- name: inline
+  path: /users/1
+  method: GET
+  response_schema:
+    type: object
+    required: [id]
+`
+
+const userSchemaFile = `{
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer"},
+		"name": {"type": "string"}
+	}
+}`
+
+func TestResponseSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smoke")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "schemas"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "schemas", "user.json"), []byte(userSchemaFile), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test.yaml"), []byte(schemaTestFile), 0644))
+
+	test, err := NewTest(filepath.Join(dir, "test.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	fromFile, inline := test.Contracts[0], test.Contracts[1]
+
+	assert.NoError(t, validateSchema(fromFile, []byte(`{"id": 1, "name": "ann"}`)))
+	assert.EqualError(t, validateSchema(fromFile, []byte(`{"id": "1"}`)),
+		`response body does not match schema: #: missing required property "name"; #/id: expected integer, got string`)
+
+	assert.NoError(t, validateSchema(inline, []byte(`{"id": 1}`)))
+	assert.EqualError(t, validateSchema(inline, []byte(`[]`)), `response body does not match schema: #: expected object, got array`)
+	assert.Error(t, validateSchema(inline, []byte(`not json`)))
+}
+
+func TestResponseSchemaMissingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smoke")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test.yaml"), []byte(schemaTestFile), 0644))
+
+	_, err = NewTest(filepath.Join(dir, "test.yaml"))
+	assert.Error(t, err, "a missing schema file should be reported when the test is loaded")
+}
@@ -0,0 +1,69 @@
+package tester
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// SummaryReporter is a Reporter which collects the results of several test suites, to summarize them once they
+// have all run
+type SummaryReporter struct {
+	mu     sync.Mutex
+	suites []SuiteResult
+}
+
+// NewSummaryReporter returns an empty *SummaryReporter
+func NewSummaryReporter() *SummaryReporter {
+	return &SummaryReporter{}
+}
+
+// SuiteStarted implements Reporter
+func (r *SummaryReporter) SuiteStarted(name string, contracts int) {}
+
+// ContractStarted implements Reporter
+func (r *SummaryReporter) ContractStarted(contract Contract) {}
+
+// ContractFinished implements Reporter
+func (r *SummaryReporter) ContractFinished(result ContractResult) {}
+
+// SuiteFinished implements Reporter
+func (r *SummaryReporter) SuiteFinished(result SuiteResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.suites = append(r.suites, result)
+}
+
+// Write writes one line per test suite, passing suites to successOutput and failing ones to failureOutput, followed
+// by the overall result
+func (r *SummaryReporter) Write(successOutput, failureOutput io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var failedSuites, total, failed, skipped int
+	for _, suite := range r.suites {
+		total += suite.Total
+		failed += suite.Failed
+		skipped += suite.Skipped
+
+		if suite.SetupFailed {
+			failedSuites++
+			failure(failureOutput, suite.Name, "setup failed, tests not run")
+			continue
+		}
+		if suite.Failed > 0 {
+			failedSuites++
+			failure(failureOutput, suite.Name, "%d passed, %d failed, %d skipped", suite.Passed(), suite.Failed, suite.Skipped)
+			continue
+		}
+		success(successOutput, fmt.Sprintf("%s (%d tests)", suite.Name, suite.Total))
+	}
+
+	if failedSuites > 0 {
+		red.Fprintf(failureOutput, "FAILED (%d of %d test suites failed, %d passed, %d failed, %d skipped)\n", failedSuites, len(r.suites), total-failed-skipped, failed, skipped)
+		return
+	}
+
+	boldGreen.Fprintf(successOutput, "OK (%d test suites, %d tests)\n", len(r.suites), total)
+}
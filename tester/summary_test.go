@@ -0,0 +1,47 @@
+package tester
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryReporter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			fmt.Fprint(w, `{"token": "abc"}`)
+			return
+		}
+		fmt.Fprint(w, r.URL.Query().Get("token"))
+	}))
+	defer server.Close()
+
+	first := &Test{
+		Name: "first",
+		Contracts: []Contract{
+			{Name: "login", Path: "/token", Method: "GET", ExpectedHTTPCode: 200, Outputs: map[string]string{"token": "JSON.token"}},
+			{Name: "use", Path: "/use?token=::token::", Method: "GET", ExpectedHTTPCode: 200, ExpectedResponses: []string{"abc"}},
+		},
+	}
+	// the outputs of the first suite are not visible to the second one
+	second := &Test{
+		Name: "second",
+		Contracts: []Contract{
+			{Name: "use", Path: "/use?token=::token::", Method: "GET", ExpectedHTTPCode: 200},
+		},
+	}
+
+	summary := NewSummaryReporter()
+	assert.True(t, NewRunner(server.URL, first, WithReporter(summary)).Run())
+	assert.False(t, NewRunner(server.URL, second, WithReporter(summary)).Run())
+
+	var successOutput, failureOutput bytes.Buffer
+	summary.Write(&successOutput, &failureOutput)
+
+	assert.Equal(t, good+"\tfirst (2 tests)\n", successOutput.String())
+	assert.Equal(t, bad+"\tsecond: 0 passed, 1 failed, 0 skipped\nFAILED (1 of 2 test suites failed, 2 passed, 1 failed, 0 skipped)\n", failureOutput.String())
+}
@@ -0,0 +1,961 @@
+package tester
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluehoodie/smoke/internal/jsonschema"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Contract represents the data for a single test case: the definition of the HTTP call and the expected result
+type Contract struct {
+	Name    string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Path    string            `json:"path,omitempty" yaml:"path,omitempty"`
+	Method  string            `json:"method,omitempty" yaml:"method,omitempty"`
+	Body    string            `json:"body,omitempty" yaml:"body,omitempty"`
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	Locals map[string]string `json:"locals,omitempty" yaml:"locals,omitempty"`
+
+	Outputs map[string]string `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+
+	// DependsOn are the names of earlier contracts which must pass for this contract to run.  The contract is skipped
+	// when any of them failed or was skipped.
+	DependsOn []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+
+	// Tags label the contract, for a Filter to select it
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	ExpectedHTTPCode     int               `json:"http_code_is,omitempty" yaml:"http_code_is,omitempty"`
+	ExpectedResponseBody string            `json:"response_body_contains,omitempty" yaml:"response_body_contains,omitempty"`
+	ExpectedResponses    []string          `json:"response_contains,omitempty" yaml:"response_contains,omitempty"`
+	ExpectedHeaders      map[string]string `json:"response_headers_contain,omitempty" yaml:"response_headers_contain,omitempty"`
+
+	// ExpectedCookies are the cookies the response must set, by name
+	ExpectedCookies map[string]*CookieAssertion `json:"response_cookies,omitempty" yaml:"response_cookies,omitempty"`
+	// ResetCookies empties the cookie jar of the Test before the request is sent
+	ResetCookies bool `json:"reset_cookies,omitempty" yaml:"reset_cookies,omitempty"`
+
+	PeerCertificate *CertificateAssertion `json:"peer_certificate,omitempty" yaml:"peer_certificate,omitempty"`
+
+	// FollowRedirects overrides the redirect policy of the http client for this contract
+	FollowRedirects *FollowRedirects `json:"follow_redirects,omitempty" yaml:"follow_redirects,omitempty"`
+	RedirectChain   []Redirect       `json:"redirect_chain,omitempty" yaml:"redirect_chain,omitempty"`
+
+	// ResponseTimeUnder is the time the response must be received and read in, overriding the default of the Test
+	ResponseTimeUnder *Duration `json:"response_time_under,omitempty" yaml:"response_time_under,omitempty"`
+
+	JSONBodyMatches map[string]interface{} `json:"json_body_matches,omitempty" yaml:"json_body_matches,omitempty"`
+	ResponseSchema  interface{}            `json:"response_schema,omitempty" yaml:"response_schema,omitempty"`
+
+	Retry *Retry `json:"retry,omitempty" yaml:"retry,omitempty"`
+	Auth  *Auth  `json:"auth,omitempty" yaml:"auth,omitempty"`
+
+	// Extends is the name of a template of the Test this contract inherits from
+	Extends string `json:"extends,omitempty" yaml:"extends,omitempty"`
+
+	// responses and headers are the compiled ExpectedResponses and ExpectedHeaders
+	responses    []pattern
+	headers      map[string]pattern
+	outputs      map[string]output
+	jsonMatchers []jsonMatcher
+	schema       *jsonschema.Schema
+	// schemaDir is the directory the ResponseSchema file is relative to
+	schemaDir string
+	// file is the test file defining the contract
+	file string
+	// invalid holds the problems found compiling the contract of a Test which was not created by NewTest
+	invalid error
+}
+
+// Test represents the data for a full test suite
+type Test struct {
+	Name      string            `json:"name,omitempty" yaml:"name,omitempty"`
+	Globals   map[string]string `json:"globals,omitempty" yaml:"globals,omitempty"`
+	Contracts []Contract        `json:"contracts,omitempty" yaml:"contracts,omitempty"`
+
+	// Setup contracts run one at a time before the other contracts, which are not run if any of them fails.  Their
+	// outputs are available to every other contract.
+	Setup []Contract `json:"setup,omitempty" yaml:"setup,omitempty"`
+	// Teardown contracts run one at a time after the other contracts, even when some of them failed
+	Teardown []Contract `json:"teardown,omitempty" yaml:"teardown,omitempty"`
+
+	// Include lists other test files, or glob patterns, relative to this one whose globals, templates and contracts
+	// are added to this Test
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+	// Templates are partial contracts which contracts can extend
+	Templates map[string]Contract `json:"templates,omitempty" yaml:"templates,omitempty"`
+
+	// Retry is the default retry of the contracts which do not define their own
+	Retry *Retry `json:"retry,omitempty" yaml:"retry,omitempty"`
+	// Auth is the default authentication of the contracts which do not define their own
+	Auth *Auth `json:"auth,omitempty" yaml:"auth,omitempty"`
+	// ResponseTimeUnder is the default response time budget of the contracts which do not define their own
+	ResponseTimeUnder *Duration `json:"response_time_under,omitempty" yaml:"response_time_under,omitempty"`
+	// CookieJar keeps the cookies set by the responses, and sends them with the following requests
+	CookieJar bool `json:"cookie_jar,omitempty" yaml:"cookie_jar,omitempty"`
+
+	// TLS holds the TLS settings used to connect to the service
+	TLS *TLS `json:"tls,omitempty" yaml:"tls,omitempty"`
+
+	// Environments are the deployments the Test can be run against, by name
+	Environments map[string]*Environment `json:"environments,omitempty" yaml:"environments,omitempty"`
+
+	// dir is the directory of the test file, against which the files it references are resolved
+	dir       string
+	tlsConfig *tls.Config
+	// compiled makes sure the contracts of a Test which was not created by NewTest are compiled only once
+	compiled sync.Once
+}
+
+// NewTest returns an initialized *Test and any error encountered along the way
+func NewTest(inputFile string) (*Test, error) {
+	t, err := loadTest(inputFile, make(map[string]bool))
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Name == "" {
+		t.Name = inputFile
+	}
+
+	if err := t.init(); err != nil {
+		return nil, errors.Wrap(err, "invalid test data")
+	}
+
+	return t, nil
+}
+
+func (t *Test) init() error {
+	if t == nil {
+		return nil
+	}
+
+	if err := t.resolveTemplates(); err != nil {
+		return err
+	}
+
+	if err := t.initSettings(); err != nil {
+		return err
+	}
+
+	// every invalid contract is reported, rather than only the first one
+	var failures []string
+	earlier := make(map[string]bool)
+	for _, phase := range t.phases() {
+		for i := range phase.contracts {
+			contract := &phase.contracts[i]
+			for _, err := range t.initContract(contract) {
+				failures = append(failures, fmt.Sprintf("%s: %v", phase.describe(contract.Name), err))
+			}
+			if err := checkDependsOn(*contract, earlier); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", phase.describe(contract.Name), err))
+			}
+			earlier[contract.Name] = true
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// initSettings checks the settings of the Test and of its environments
+func (t *Test) initSettings() error {
+	if t.Auth != nil {
+		if err := t.Auth.init(); err != nil {
+			return errors.Wrap(err, "auth")
+		}
+	}
+
+	if t.TLS != nil {
+		config, err := t.TLS.Config(t.dir)
+		if err != nil {
+			return errors.Wrap(err, "tls")
+		}
+		t.tlsConfig = config
+	}
+
+	for name, env := range t.Environments {
+		if env == nil {
+			return fmt.Errorf("environment %v: no settings", name)
+		}
+		if err := env.init(t.dir); err != nil {
+			return errors.Wrapf(err, "environment %v", name)
+		}
+	}
+
+	if t.Retry != nil {
+		if err := t.Retry.init(); err != nil {
+			return errors.Wrap(err, "retry")
+		}
+	}
+
+	if t.ResponseTimeUnder != nil && *t.ResponseTimeUnder <= 0 {
+		return fmt.Errorf("response_time_under must be positive, got %v", *t.ResponseTimeUnder)
+	}
+
+	return nil
+}
+
+// initContract checks the settings of a contract and compiles its expressions.  Every problem is returned, each
+// beginning by the field in error.
+func (t *Test) initContract(contract *Contract) []error {
+	errs := contract.compilePatterns()
+
+	if contract.Retry != nil {
+		if err := contract.Retry.init(); err != nil {
+			errs = append(errs, errors.Wrap(err, "retry"))
+		}
+	}
+
+	if contract.Auth != nil {
+		if err := contract.Auth.init(); err != nil {
+			errs = append(errs, errors.Wrap(err, "auth"))
+		}
+	}
+
+	if contract.ResponseTimeUnder != nil && *contract.ResponseTimeUnder <= 0 {
+		errs = append(errs, fmt.Errorf("response_time_under must be positive, got %v", *contract.ResponseTimeUnder))
+	}
+
+	if len(contract.Outputs) > 0 {
+		outputs, err := compileOutputs(contract.Outputs)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "outputs"))
+		}
+		contract.outputs = outputs
+	}
+
+	if len(contract.JSONBodyMatches) > 0 {
+		matchers, err := compileJSONMatchers(contract.JSONBodyMatches)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "json_body_matches"))
+		}
+		contract.jsonMatchers = matchers
+	}
+
+	if len(contract.RedirectChain) > 0 {
+		if contract.FollowRedirects == nil {
+			errs = append(errs, fmt.Errorf("redirect_chain requires follow_redirects"))
+		}
+		if err := initRedirectChain(contract.RedirectChain); err != nil {
+			errs = append(errs, errors.Wrap(err, "redirect_chain"))
+		}
+	}
+
+	if contract.ResetCookies && !t.CookieJar {
+		errs = append(errs, fmt.Errorf("reset_cookies requires cookie_jar"))
+	}
+
+	cookies := make([]string, 0, len(contract.ExpectedCookies))
+	for name := range contract.ExpectedCookies {
+		cookies = append(cookies, name)
+	}
+	sort.Strings(cookies)
+	for _, name := range cookies {
+		cookie := contract.ExpectedCookies[name]
+		if cookie == nil {
+			continue
+		}
+		if err := cookie.init(); err != nil {
+			errs = append(errs, errors.Wrapf(err, "response_cookies: %v", name))
+		}
+	}
+
+	if contract.PeerCertificate != nil {
+		if err := contract.PeerCertificate.init(); err != nil {
+			errs = append(errs, errors.Wrap(err, "peer_certificate"))
+		}
+	}
+
+	if contract.ResponseSchema != nil {
+		dir := contract.schemaDir
+		if dir == "" {
+			dir = t.dir
+		}
+		schema, err := loadSchema(contract.ResponseSchema, dir)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, "response_schema"))
+		}
+		contract.schema = schema
+	}
+
+	return errs
+}
+
+// compilePatterns compiles the expected responses and headers of a contract, and reports every invalid one
+func (contract *Contract) compilePatterns() []error {
+	if contract.ExpectedResponseBody != "" {
+		contract.ExpectedResponses = append(contract.ExpectedResponses, contract.ExpectedResponseBody)
+	}
+
+	var errs []error
+
+	contract.responses = make([]pattern, 0, len(contract.ExpectedResponses))
+	for _, expected := range contract.ExpectedResponses {
+		p, err := compilePattern(expected)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "response_body_contains %s", expected))
+			continue
+		}
+		contract.responses = append(contract.responses, p)
+	}
+
+	keys := make([]string, 0, len(contract.ExpectedHeaders))
+	for key := range contract.ExpectedHeaders {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	contract.headers = make(map[string]pattern, len(contract.ExpectedHeaders))
+	for _, key := range keys {
+		p, err := compilePattern(contract.ExpectedHeaders[key])
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "response_headers_contain %s", key))
+			continue
+		}
+		contract.headers[key] = p
+	}
+
+	return errs
+}
+
+// compileContracts compiles the contracts of a Test which was not created by NewTest, before any of them runs, so that
+// the runs never write to the contracts they share.  The problems found are reported when the contracts are checked.
+func (t *Test) compileContracts() {
+	t.compiled.Do(func() {
+		for _, phase := range t.phases() {
+			for i := range phase.contracts {
+				contract := &phase.contracts[i]
+				if contract.responses != nil {
+					continue
+				}
+				errs := t.initContract(contract)
+				if contract.Auth == nil && t.Auth != nil {
+					if err := t.Auth.init(); err != nil {
+						errs = append(errs, errors.Wrap(err, "auth"))
+					}
+				}
+				if len(errs) > 0 {
+					contract.invalid = joinErrors(errs)
+				}
+			}
+		}
+	})
+}
+
+// joinErrors returns an error with the messages of every error
+func joinErrors(errs []error) error {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Errorf("%s", strings.Join(messages, "; "))
+}
+
+// contractPhase is a list of contracts of a Test which run together
+type contractPhase struct {
+	// name is setup or teardown, and empty for the contracts of the Test
+	name      string
+	contracts []Contract
+}
+
+// phases returns the contracts of the Test by phase, in the order they run
+func (t *Test) phases() []contractPhase {
+	return []contractPhase{{"setup", t.Setup}, {"", t.Contracts}, {"teardown", t.Teardown}}
+}
+
+// describe returns the name of a contract of the phase, as used in error messages
+func (p contractPhase) describe(name string) string {
+	if p.name == "" {
+		return "contract " + name
+	}
+	return p.name + " contract " + name
+}
+
+// Runner is the primary struct of this package and is responsible for running the test suite
+type Runner struct {
+	successOutput io.Writer
+	failureOutput io.Writer
+
+	client *http.Client
+
+	parallelism int
+	failFast    bool
+	reporters   []Reporter
+	validators  []ResponseValidator
+
+	test        *Test
+	url         string
+	globals     *variableStore
+	environment *Environment
+	jar         *sessionJar
+
+	authenticator *authenticator
+
+	// setupFailed is set when a setup contract failed during the last Run
+	setupFailed bool
+
+	// outputMu serializes the calls to the reporters when contracts run concurrently
+	outputMu sync.Mutex
+}
+
+// ResponseValidator checks every response received by a Runner, in addition to the assertions of each contract.
+// It may be called concurrently when contracts run in parallel.
+type ResponseValidator interface {
+	ValidateResponse(resp *http.Response, body []byte) error
+}
+
+// Option is a function which can change some properties of the Runner
+type Option func(*Runner)
+
+// WithVerboseModeOn returns an Option which sets the verbosity of the runner.  Default is false.
+func WithVerboseModeOn(verboseMode bool) Option {
+	return func(r *Runner) {
+		if verboseMode {
+			r.successOutput = os.Stdout
+			r.failureOutput = os.Stdout
+		}
+	}
+}
+
+// WithHTTPClient returns an Option which overrides the default http client
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *Runner) {
+		r.client = client
+	}
+}
+
+// WithParallelism returns an Option which sets the maximum number of contracts run concurrently.  Contracts which
+// depend on the outputs of other contracts always run after them, and the contracts of a Test with a CookieJar run
+// one at a time.  Default is 1.
+func WithParallelism(n int) Option {
+	return func(r *Runner) {
+		if n > 0 {
+			r.parallelism = n
+		}
+	}
+}
+
+// WithFailFast returns an Option which stops running the contracts after the first one failing, the others being
+// reported as skipped.  The teardown contracts still run.  Default is false.
+func WithFailFast(failFast bool) Option {
+	return func(r *Runner) {
+		r.failFast = failFast
+	}
+}
+
+// WithReporter returns an Option which adds a Reporter to be notified of the results, in addition to the
+// default terminal output.
+func WithReporter(reporter Reporter) Option {
+	return func(r *Runner) {
+		r.reporters = append(r.reporters, reporter)
+	}
+}
+
+// WithResponseValidator returns an Option which adds a ResponseValidator, failing any contract whose response it
+// rejects
+func WithResponseValidator(validator ResponseValidator) Option {
+	return func(r *Runner) {
+		r.validators = append(r.validators, validator)
+	}
+}
+
+// NewRunner returns a *Runner for a given url and Test.
+func NewRunner(url string, test *Test, opts ...Option) *Runner {
+	runner := &Runner{
+		url:     url,
+		test:    test,
+		globals: newVariableStore(test.Globals),
+
+		client:        http.DefaultClient,
+		parallelism:   1,
+		successOutput: ioutil.Discard,
+		failureOutput: os.Stderr,
+	}
+
+	for _, opt := range opts {
+		opt(runner)
+	}
+
+	test.compileContracts()
+	runner.applyEnvironment()
+	runner.applyTLS()
+	runner.applyCookieJar()
+	runner.authenticator = newAuthenticator(runner.client, runner.url)
+
+	runner.reporters = append([]Reporter{&terminalReporter{
+		successOutput: runner.successOutput,
+		failureOutput: runner.failureOutput,
+	}}, runner.reporters...)
+
+	return runner
+}
+
+// Run is the method which runs the Test associated with this Runner.
+// Returns a bool representing the result of the test.
+func (runner *Runner) Run() bool {
+	runner.report(func(reporter Reporter) {
+		reporter.SuiteStarted(runner.test.Name, len(runner.test.Setup)+len(runner.test.Contracts)+len(runner.test.Teardown))
+	})
+
+	start := time.Now()
+
+	results, setupFailure := runner.runSetup()
+	runner.setupFailed = setupFailure != ""
+
+	contracts := make([]Contract, len(runner.test.Contracts))
+	for i, contract := range runner.test.Contracts {
+		contracts[i] = runner.prepare(contract)
+	}
+	if runner.setupFailed {
+		for _, contract := range contracts {
+			results = append(results, runner.skipContract("", contract, setupFailure))
+		}
+	} else {
+		results = append(results, runner.runContracts(contracts, results)...)
+	}
+
+	results = append(results, runner.runTeardown(results)...)
+
+	suite := SuiteResult{
+		Name:        runner.test.Name,
+		Total:       len(results),
+		Duration:    time.Since(start),
+		Contracts:   results,
+		SetupFailed: runner.setupFailed,
+	}
+	for _, result := range results {
+		switch {
+		case result.Skipped():
+			suite.Skipped++
+		case result.Err != nil:
+			suite.Failed++
+		}
+	}
+
+	runner.report(func(reporter Reporter) {
+		reporter.SuiteFinished(suite)
+	})
+
+	return suite.Failed == 0
+}
+
+// SetupFailed returns whether a setup contract failed during the last Run, in which case the other contracts of the
+// Test were not run
+func (runner *Runner) SetupFailed() bool {
+	return runner.setupFailed
+}
+
+// runSetup runs the setup contracts one at a time.  Once one of them fails the others are skipped, and the reason
+// they were skipped is returned for the contracts of the Test to be skipped too.
+func (runner *Runner) runSetup() (results []ContractResult, failure string) {
+	for _, contract := range runner.test.Setup {
+		contract = runner.prepare(contract)
+		if failure != "" {
+			results = append(results, runner.skipContract("setup", contract, failure))
+			continue
+		}
+
+		result := runner.runContract("setup", contract)
+		results = append(results, result)
+		if result.Err != nil {
+			failure = fmt.Sprintf("setup contract %v failed", contract.Name)
+		}
+	}
+	return results, failure
+}
+
+// runTeardown runs every teardown contract one at a time, except those depending on a contract which did not pass.
+// completed are the results of the contracts run before.
+func (runner *Runner) runTeardown(completed []ContractResult) []ContractResult {
+	completed = append([]ContractResult{}, completed...)
+
+	var results []ContractResult
+	for _, contract := range runner.test.Teardown {
+		contract = runner.prepare(contract)
+
+		var result ContractResult
+		if reason := skipReason(contract, completed); reason != "" {
+			result = runner.skipContract("teardown", contract, reason)
+		} else {
+			result = runner.runContract("teardown", contract)
+		}
+		results = append(results, result)
+		completed = append(completed, result)
+	}
+	return results
+}
+
+// runContracts runs every contract and returns their results in the same order as the contracts.  completed are the
+// results of the setup contracts, which the contracts may depend on.
+func (runner *Runner) runContracts(contracts []Contract, completed []ContractResult) []ContractResult {
+	results := make([]ContractResult, len(contracts))
+
+	// stopped is the reason the contracts not started yet are skipped, once a contract failed in fail fast mode
+	var mu sync.Mutex
+	var stopped string
+
+	// run runs a contract, or skips it when a contract it depends on did not pass
+	run := func(i int, dependencies []ContractResult) {
+		mu.Lock()
+		reason := stopped
+		mu.Unlock()
+		if reason == "" {
+			reason = skipReason(contracts[i], dependencies)
+		}
+		if reason != "" {
+			results[i] = runner.skipContract("", contracts[i], reason)
+			return
+		}
+
+		results[i] = runner.runContract("", contracts[i])
+		if results[i].Err != nil && runner.failFast {
+			mu.Lock()
+			if stopped == "" {
+				stopped = fmt.Sprintf("stopped after contract %v failed", contracts[i].Name)
+			}
+			mu.Unlock()
+		}
+	}
+
+	// contracts sharing a cookie jar may depend on the cookies set by any of the previous ones
+	if runner.parallelism <= 1 || runner.jar != nil {
+		for i := range contracts {
+			run(i, append(append([]ContractResult{}, completed...), results[:i]...))
+		}
+		return results
+	}
+
+	deps := buildDependencies(contracts)
+
+	done := make([]chan struct{}, len(contracts))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	sem := make(chan struct{}, runner.parallelism)
+
+	var wg sync.WaitGroup
+	for i := range contracts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer close(done[i])
+
+			dependencies := append([]ContractResult{}, completed...)
+			for _, d := range deps[i] {
+				<-done[d]
+				dependencies = append(dependencies, results[d])
+			}
+
+			sem <- struct{}{}
+			run(i, dependencies)
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// prepare returns the contract with the defaults of the Test and of the environment it does not define itself
+func (runner *Runner) prepare(contract Contract) Contract {
+	if contract.Auth == nil {
+		contract.Auth = runner.test.Auth
+	}
+	return runner.withEnvironmentHeaders(contract)
+}
+
+// runContract runs a contract of a phase, empty for the contracts of the Test, and reports its result
+func (runner *Runner) runContract(phase string, contract Contract) ContractResult {
+	runner.report(func(reporter Reporter) {
+		reporter.ContractStarted(contract)
+	})
+
+	result := ContractResult{Name: contract.Name, Phase: phase}
+
+	start := time.Now()
+	result.Err = runner.validateContract(contract, &result)
+	result.Duration = time.Since(start)
+
+	runner.report(func(reporter Reporter) {
+		reporter.ContractFinished(result)
+	})
+
+	return result
+}
+
+// skipContract reports a contract which is not run, with the reason it is skipped
+func (runner *Runner) skipContract(phase string, contract Contract, reason string) ContractResult {
+	result := ContractResult{Name: contract.Name, Phase: phase, SkipReason: reason}
+
+	runner.report(func(reporter Reporter) {
+		reporter.ContractFinished(result)
+	})
+
+	return result
+}
+
+// report calls fn for every reporter of the runner, making sure the reporters are never called concurrently
+func (runner *Runner) report(fn func(Reporter)) {
+	runner.outputMu.Lock()
+	defer runner.outputMu.Unlock()
+
+	for _, reporter := range runner.reporters {
+		fn(reporter)
+	}
+}
+
+func (runner *Runner) validateContract(contract Contract, result *ContractResult) error {
+	if err := parseVariables(runner, &contract); err != nil {
+		return err
+	}
+
+	if contract.ResetCookies && runner.jar != nil {
+		runner.jar.reset()
+	}
+
+	retry := contract.Retry
+	if retry == nil {
+		retry = runner.test.Retry
+	}
+
+	return retryContract(retry, result, func() (bool, error) {
+		return runner.attemptContract(contract, retry, result)
+	})
+}
+
+// attemptContract sends the request of a contract and validates its response.  done is false when the contract
+// should be attempted again.
+func (runner *Runner) attemptContract(contract Contract, retry *Retry, result *ContractResult) (done bool, err error) {
+	result.Request = newRequestDetails(contract, runner.url)
+	result.Response = nil
+	result.Timings = nil
+
+	trace := &requestTrace{}
+
+	var resp *http.Response
+	resp, err = createAndSendRequest(contract, runner.url, runner.client, runner.authenticator, trace)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("could not read response body: %v", err)
+	}
+
+	timings := trace.done()
+	result.Timings = &timings
+
+	result.Response = &Response{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Header,
+		Body:       body,
+	}
+
+	if retry != nil && retry.Until != nil {
+		if err = retry.Until.check(resp, body); err != nil {
+			return false, err
+		}
+		return true, runner.validateResponse(contract, resp, body, timings)
+	}
+
+	err = runner.validateResponse(contract, resp, body, timings)
+	return err == nil, err
+}
+
+func (runner *Runner) validateResponse(contract Contract, resp *http.Response, body []byte, timings Timings) (err error) {
+	if contract.invalid != nil {
+		return contract.invalid
+	}
+
+	if err = validateHTTPCode(contract, resp); err != nil {
+		return err
+	}
+
+	if len(contract.RedirectChain) > 0 {
+		if err = validateRedirectChain(contract, resp); err != nil {
+			return err
+		}
+	}
+
+	if len(contract.ExpectedHeaders) > 0 {
+		if err = validateHeaders(contract, resp); err != nil {
+			return err
+		}
+	}
+
+	if len(contract.ExpectedCookies) > 0 {
+		if err = validateCookies(contract, resp); err != nil {
+			return err
+		}
+	}
+
+	if err = validateResponseBody(contract, body); err != nil {
+		return err
+	}
+
+	if contract.PeerCertificate != nil {
+		if err = contract.PeerCertificate.validate(resp.TLS); err != nil {
+			return err
+		}
+	}
+
+	if len(contract.JSONBodyMatches) > 0 {
+		if err = validateJSONBody(contract, body); err != nil {
+			return err
+		}
+	}
+
+	if contract.ResponseSchema != nil {
+		if err = validateSchema(contract, body); err != nil {
+			return err
+		}
+	}
+
+	for _, validator := range runner.validators {
+		if err = validator.ValidateResponse(resp, body); err != nil {
+			return err
+		}
+	}
+
+	budget := contract.ResponseTimeUnder
+	if budget == nil {
+		budget = runner.test.ResponseTimeUnder
+	}
+	if err = validateResponseTime(budget, timings); err != nil {
+		return err
+	}
+
+	if err = parseOutputs(runner, &contract, resp, body); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func newRequestDetails(contract Contract, url string) *Request {
+	headers := make(http.Header, len(contract.Headers))
+	for key, value := range contract.Headers {
+		headers.Set(key, value)
+	}
+
+	return &Request{
+		Method:  strings.ToUpper(contract.Method),
+		URL:     strings.Join([]string{url, contract.Path}, ""),
+		Headers: headers,
+		Body:    contract.Body,
+	}
+}
+
+func createAndSendRequest(contract Contract, url string, client *http.Client, authenticator *authenticator, trace *requestTrace) (*http.Response, error) {
+	// create request
+	uri := strings.Join([]string{url, contract.Path}, "")
+	req, err := http.NewRequest(strings.ToUpper(contract.Method), uri, strings.NewReader(contract.Body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create http request: %v", err)
+	}
+
+	// set headers
+	for key, value := range contract.Headers {
+		req.Header.Set(key, value)
+	}
+
+	// authenticate
+	if err := authenticator.apply(req, contract.Auth); err != nil {
+		return nil, fmt.Errorf("could not authenticate request: %v", err)
+	}
+
+	if contract.FollowRedirects != nil {
+		client = withRedirects(client, *contract.FollowRedirects)
+	}
+
+	// send request, measuring the time it takes from here
+	req = req.WithContext(trace.withContext(req.Context()))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %v", err)
+	}
+
+	return resp, nil
+}
+
+func validateHTTPCode(contract Contract, resp *http.Response) error {
+	if contract.ExpectedHTTPCode != 0 {
+		if resp.StatusCode != contract.ExpectedHTTPCode {
+			return fmt.Errorf("expected http response code %d got %d", contract.ExpectedHTTPCode, resp.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+func validateResponseBody(contract Contract, body []byte) error {
+	for _, p := range contract.responses {
+		if p.re != nil {
+			if !p.re.Match(body) {
+				return fmt.Errorf("regular expression did not find any matches in the response body")
+			}
+		} else if !bytes.Contains(body, []byte(p.literal)) {
+			return fmt.Errorf("expected response not found in the body")
+		}
+	}
+
+	return nil
+}
+
+func validateHeaders(contract Contract, resp *http.Response) error {
+	for k, p := range contract.headers {
+		if val, ok := resp.Header[k]; ok && len(val) > 0 {
+			if p.isZero() {
+				continue
+			}
+
+			if p.re != nil {
+				if !p.matchString(val[0]) {
+					return fmt.Errorf("regular expression did not find any matches in header %s value %s", k, val[0])
+				}
+			} else if !p.matchString(val[0]) {
+				return fmt.Errorf("expected header %s value %s got %s ", k, p.literal, val[0])
+			}
+		} else {
+			return fmt.Errorf("expected header %s not found in the response", k)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalInputFile(filename string, in []byte, out interface{}) error {
+	var unmarshalError error
+
+	ext := strings.Trim(path.Ext(filename), ".")
+	switch ext {
+	case "yaml":
+		fallthrough
+	case "yml":
+		unmarshalError = yaml.Unmarshal(in, out)
+	default:
+		unmarshalError = json.Unmarshal(in, out)
+	}
+
+	return unmarshalError
+}
@@ -0,0 +1,113 @@
+package tester
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings holds the time spent in each phase of the http request of a contract.  The phases are 0 when they did not
+// happen, such as DNS, Connect and TLS when a connection is reused.  When redirects are followed, the phases of every
+// request are added.
+type Timings struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// FirstByte is the time from sending the request until the first byte of the response is received
+	FirstByte time.Duration
+	// Total is the time from sending the request until the response body is read
+	Total time.Duration
+}
+
+func (t Timings) String() string {
+	return fmt.Sprintf("dns %v, connect %v, tls %v, first byte %v, total %v",
+		roundTiming(t.DNS), roundTiming(t.Connect), roundTiming(t.TLS), roundTiming(t.FirstByte), roundTiming(t.Total))
+}
+
+func roundTiming(d time.Duration) time.Duration {
+	if d > time.Millisecond {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+// requestTrace measures the Timings of a request with an httptrace.ClientTrace
+type requestTrace struct {
+	mu      sync.Mutex
+	start   time.Time
+	timings Timings
+
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+}
+
+// withContext returns a context tracing the requests made with it, and starts measuring
+func (t *requestTrace) withContext(ctx context.Context) context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.start = time.Now()
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.DNS += time.Since(t.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// several addresses may be dialed at once, the connection starts with the first of them
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil && !t.connectStart.IsZero() {
+				t.timings.Connect += time.Since(t.connectStart)
+				t.connectStart = time.Time{}
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.TLS += time.Since(t.tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.FirstByte = time.Since(t.start)
+		},
+	})
+}
+
+// done stops measuring, once the response body is read
+func (t *requestTrace) done() Timings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.timings.Total = time.Since(t.start)
+	return t.timings
+}
+
+// validateResponseTime fails when the response took longer than the budget of the contract
+func validateResponseTime(budget *Duration, timings Timings) error {
+	if budget == nil || timings.Total < time.Duration(*budget) {
+		return nil
+	}
+	return fmt.Errorf("expected response time under %v, took %v", *budget, roundTiming(timings.Total))
+}
@@ -0,0 +1,82 @@
+package tester
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseTimeUnder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	fast, slow := Duration(10*time.Millisecond), Duration(time.Second)
+
+	test := &Test{
+		ResponseTimeUnder: &fast,
+		Contracts: []Contract{
+			{Name: "fast", Path: "/fast"},
+			{Name: "slow", Path: "/slow"},
+			{Name: "slow with its own budget", Path: "/slow", ResponseTimeUnder: &slow},
+		},
+	}
+	assert.NoError(t, test.init())
+
+	reporter := &recordingReporter{}
+	assert.False(t, NewRunner(server.URL, test, WithReporter(reporter)).Run())
+
+	results := reporter.results
+	assert.NoError(t, results[0].Err)
+	assert.Regexp(t, regexp.MustCompile(`^expected response time under 10ms, took \d+ms$`), results[1].Err)
+	assert.NoError(t, results[2].Err)
+
+	timings := results[1].Timings
+	if assert.NotNil(t, timings) {
+		assert.True(t, timings.FirstByte >= 50*time.Millisecond, "first byte %v", timings.FirstByte)
+		assert.True(t, timings.Total >= timings.FirstByte, "total %v", timings.Total)
+	}
+}
+
+func TestTimings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	test := &Test{Contracts: []Contract{{Name: "first", Path: "/"}, {Name: "second", Path: "/"}}}
+	reporter := &recordingReporter{}
+	assert.True(t, NewRunner(server.URL, test, WithHTTPClient(server.Client()), WithReporter(reporter)).Run())
+
+	first, second := reporter.results[0].Timings, reporter.results[1].Timings
+	assert.True(t, first.Connect > 0, "connect %v", first.Connect)
+	assert.True(t, first.TLS > 0, "tls %v", first.TLS)
+	assert.True(t, first.FirstByte > 0, "first byte %v", first.FirstByte)
+
+	// the connection of the first request is reused
+	assert.Equal(t, time.Duration(0), second.Connect)
+	assert.Equal(t, time.Duration(0), second.TLS)
+}
+
+func TestInvalidResponseTimeUnder(t *testing.T) {
+	zero := Duration(0)
+
+	test := &Test{ResponseTimeUnder: &zero}
+	assert.EqualError(t, test.init(), "response_time_under must be positive, got 0s")
+
+	test = &Test{Contracts: []Contract{{Name: "zero", ResponseTimeUnder: &zero}}}
+	assert.EqualError(t, test.init(), "contract zero: response_time_under must be positive, got 0s")
+}
+
+func TestTimingsString(t *testing.T) {
+	timings := Timings{DNS: 1500 * time.Microsecond, Connect: 250 * time.Microsecond, FirstByte: 20 * time.Millisecond, Total: 21 * time.Millisecond}
+	assert.Equal(t, "dns 2ms, connect 250µs, tls 0s, first byte 20ms, total 21ms", timings.String())
+}
@@ -0,0 +1,136 @@
+package tester
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TLS holds the TLS settings used to connect to the service.  Files are PEM encoded.
+type TLS struct {
+	// CACert is a file of the certificate authorities trusted to verify the service, instead of the system ones
+	CACert string `json:"ca_cert,omitempty" yaml:"ca_cert,omitempty"`
+	// Cert and Key are the files of the client certificate and of its private key, for mutual TLS
+	Cert string `json:"cert,omitempty" yaml:"cert,omitempty"`
+	Key  string `json:"key,omitempty" yaml:"key,omitempty"`
+	// ServerName is the name the certificate of the service is verified against, instead of the host of the url
+	ServerName string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+	// MinVersion is the minimum TLS version accepted: 1.0, 1.1, 1.2 or 1.3
+	MinVersion string `json:"min_version,omitempty" yaml:"min_version,omitempty"`
+	// Insecure disables the verification of the certificate of the service
+	Insecure bool `json:"insecure,omitempty" yaml:"insecure,omitempty"`
+}
+
+// Config returns the *tls.Config for these settings, with files relative to dir
+func (t *TLS) Config(dir string) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.Insecure,
+	}
+
+	if t.MinVersion != "" {
+		version, ok := tlsVersions[t.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid min_version %q: expected 1.0, 1.1, 1.2 or 1.3", t.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if t.CACert != "" {
+		file := relativeTo(dir, t.CACert)
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read ca_cert %v", file)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificate found in ca_cert %v", file)
+		}
+		config.RootCAs = pool
+	}
+
+	if t.Cert != "" || t.Key != "" {
+		if t.Cert == "" || t.Key == "" {
+			return nil, fmt.Errorf("cert and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(relativeTo(dir, t.Cert), relativeTo(dir, t.Key))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load client certificate")
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// relativeTo returns file relative to dir, unless it is absolute
+func relativeTo(dir, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(dir, file)
+}
+
+// applyTLS configures the client of the runner with the TLS settings of the Test, overridden by those of the
+// environment, themselves overridden by the TLS configuration of the client given to the runner
+func (runner *Runner) applyTLS() {
+	config := runner.test.tlsConfig
+	if runner.environment != nil {
+		config = mergeTLSConfig(config, runner.environment.tlsConfig)
+	}
+	if config == nil {
+		return
+	}
+
+	var transport *http.Transport
+	if t, ok := runner.client.Transport.(*http.Transport); ok {
+		transport = t.Clone()
+	} else {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	transport.TLSClientConfig = mergeTLSConfig(config, transport.TLSClientConfig)
+
+	client := *runner.client
+	client.Transport = transport
+	runner.client = &client
+}
+
+// mergeTLSConfig returns base with the settings defined by override
+func mergeTLSConfig(base, override *tls.Config) *tls.Config {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+
+	config := base.Clone()
+	if override.RootCAs != nil {
+		config.RootCAs = override.RootCAs
+	}
+	if len(override.Certificates) > 0 {
+		config.Certificates = override.Certificates
+	}
+	if override.ServerName != "" {
+		config.ServerName = override.ServerName
+	}
+	if override.MinVersion != 0 {
+		config.MinVersion = override.MinVersion
+	}
+	if override.InsecureSkipVerify {
+		config.InsecureSkipVerify = true
+	}
+	return config
+}
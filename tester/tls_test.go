@@ -0,0 +1,124 @@
+package tester
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clientCertificate returns a self-signed client certificate and its private key, PEM encoded
+func clientCertificate(t *testing.T) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smoke"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestTestTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 || r.TLS.PeerCertificates[0].Subject.CommonName != "smoke" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	cert, key := clientCertificate(t)
+	dir := writeTestFiles(t, map[string]string{
+		"main.yaml": `
+tls:
+  ca_cert: certs/ca.pem
+  cert: certs/client.pem
+  key: certs/client-key.pem
+contracts:
+- name: mtls
+  path: /
+  method: GET
+  http_code_is: 200
+`,
+		"certs/ca.pem":         string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})),
+		"certs/client.pem":     string(cert),
+		"certs/client-key.pem": string(key),
+	})
+	defer os.RemoveAll(dir)
+
+	test, err := NewTest(filepath.Join(dir, "main.yaml"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, NewRunner(server.URL, test).Run())
+}
+
+func TestTLSPrecedence(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	test := &Test{
+		Contracts: []Contract{{Name: "tls", Path: "/", Method: "GET", ExpectedHTTPCode: 200}},
+		// the certificate of the service is not verified against the name of the url
+		tlsConfig: &tls.Config{ServerName: "wrong.test"},
+	}
+	assert.False(t, NewRunner(server.URL, test, WithHTTPClient(server.Client())).Run())
+
+	test.tlsConfig = &tls.Config{ServerName: "example.com"}
+	assert.True(t, NewRunner(server.URL, test, WithHTTPClient(server.Client())).Run())
+
+	// the environment overrides the test, and the client given to the runner overrides the environment
+	env := &Environment{tlsConfig: &tls.Config{ServerName: "wrong.test"}}
+	assert.False(t, NewRunner(server.URL, test, WithHTTPClient(server.Client()), WithEnvironment(env)).Run())
+
+	client := server.Client()
+	client.Transport.(*http.Transport).TLSClientConfig.ServerName = "127.0.0.1"
+	assert.True(t, NewRunner(server.URL, test, WithHTTPClient(client), WithEnvironment(env)).Run())
+}
+
+func TestMergeTLSConfig(t *testing.T) {
+	pool := x509.NewCertPool()
+	base := &tls.Config{ServerName: "base", MinVersion: tls.VersionTLS12, RootCAs: pool}
+	override := &tls.Config{ServerName: "override", InsecureSkipVerify: true}
+
+	merged := mergeTLSConfig(base, override)
+	assert.Equal(t, "override", merged.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS12), merged.MinVersion)
+	assert.True(t, merged.InsecureSkipVerify)
+	assert.True(t, merged.RootCAs == pool)
+
+	// base is not changed
+	assert.Equal(t, "base", base.ServerName)
+
+	assert.True(t, mergeTLSConfig(nil, override) == override)
+	assert.True(t, mergeTLSConfig(base, nil) == base)
+}
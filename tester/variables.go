@@ -0,0 +1,189 @@
+package tester
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	variableRegex *regexp.Regexp
+)
+
+func init() {
+	re, err := regexp.Compile(`::([\w]+)::`)
+	if err != nil {
+		panic(err)
+	}
+	variableRegex = re
+}
+
+// variableStore is a concurrency-safe set of variables shared by all the contracts run by a Runner
+type variableStore struct {
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+func newVariableStore(values map[string]string) *variableStore {
+	s := &variableStore{values: make(map[string]string, len(values))}
+	for k, v := range values {
+		s.values[k] = v
+	}
+	return s
+}
+
+// copy returns a new store holding the current values of s
+func (s *variableStore) copy() *variableStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return newVariableStore(s.values)
+}
+
+func (s *variableStore) get(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	val, ok := s.values[name]
+	return val, ok
+}
+
+func (s *variableStore) set(name, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[name] = value
+}
+
+func parseVariables(runner *Runner, contract *Contract) error {
+	//parse path
+	parsedPath, err := replaceVariables(runner, contract, contract.Path)
+	if err != nil {
+		return errors.Wrap(err, "could not parse path")
+	}
+	contract.Path = parsedPath
+
+	//parse body
+	parsedBody, err := replaceVariables(runner, contract, contract.Body)
+	if err != nil {
+		return errors.Wrap(err, "could not parse body")
+	}
+	contract.Body = parsedBody
+
+	//parse headers
+	// the map is shared with the Test definition, so the parsed values go into a new one
+	headers := make(map[string]string, len(contract.Headers))
+	for key, value := range contract.Headers {
+		parsedValue, err := replaceVariables(runner, contract, value)
+		if err != nil {
+			return errors.Wrap(err, "could not parse header value")
+		}
+		headers[key] = parsedValue
+	}
+	contract.Headers = headers
+
+	//parse auth
+	// the auth is also shared with the Test definition
+	if contract.Auth != nil {
+		auth := contract.Auth.copy()
+		for _, value := range auth.values() {
+			parsedValue, err := replaceVariables(runner, contract, *value)
+			if err != nil {
+				return errors.Wrap(err, "could not parse auth")
+			}
+			*value = parsedValue
+		}
+		contract.Auth = auth
+	}
+
+	return nil
+}
+
+func replaceVariables(runner *Runner, contract *Contract, s string) (string, error) {
+	matched := variableRegex.FindAllString(s, -1)
+	if len(matched) == 0 {
+		return s, nil
+	}
+
+	for _, match := range matched {
+		variableName := strings.Trim(match, "::")
+
+		var replacement string
+		var found bool
+
+		if val, ok := contract.Locals[variableName]; ok {
+			replacement = val
+			found = true
+		}
+
+		if val, ok := runner.globals.get(variableName); !found && ok {
+			replacement = val
+			found = true
+		}
+
+		if !found {
+			if val := os.Getenv(strings.ToUpper(variableName)); val != "" {
+				replacement = val
+				found = true
+			}
+		}
+
+		if found {
+			s = strings.Replace(s, match, replacement, -1)
+		} else {
+			return s, fmt.Errorf("value for variable %v not found", variableName)
+		}
+	}
+
+	return s, nil
+}
+
+func parseOutputs(runner *Runner, contract *Contract, resp *http.Response, body []byte) error {
+	outputs := contract.outputs
+	if outputs == nil {
+		var err error
+		if outputs, err = compileOutputs(contract.Outputs); err != nil {
+			return err
+		}
+	}
+
+	for key, o := range outputs {
+		result, err := o.evaluate(resp, body)
+		if err != nil {
+			return errors.Wrapf(err, "could not set output %v", key)
+		}
+		runner.globals.set(key, result)
+	}
+	return nil
+}
+
+// parseJSON returns the value selected by a JSON path expression in body
+func parseJSON(expression string, body []byte) (string, error) {
+	p, err := compileJSONPath(expression)
+	if err != nil {
+		return "", err
+	}
+
+	return evaluateJSONPath(p, body)
+}
+
+// evaluateJSONPath returns the value selected by a compiled JSON path in body
+func evaluateJSONPath(p *jsonPath, body []byte) (string, error) {
+	if len(body) == 0 {
+		return "", fmt.Errorf("no response body to parse")
+	}
+
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return "", errors.Wrap(err, "could not parse response body as json")
+	}
+
+	value, err := p.evaluate(doc)
+	if err != nil {
+		return "", err
+	}
+
+	return formatJSONValue(value), nil
+}
@@ -0,0 +1,92 @@
+package tester
+
+import (
+	"fmt"
+	"net"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// waitInterval is the time between two checks of the readiness of the service
+var waitInterval = 500 * time.Millisecond
+
+// WaitFor blocks until the service is ready, or returns an error once timeout has elapsed.  target is one of:
+//   - a path, starting with /, which must return a 2xx response to a GET request
+//   - tcp:PORT or tcp:HOST:PORT, which must accept tcp connections.  HOST defaults to the host of the runner's url
+//   - contract:NAME, a setup contract or a contract of the test which must pass.  Its outputs and cookies are not
+//     kept.  It runs before the setup, so an error is returned at once if it reads variables no global defines.
+func (runner *Runner) WaitFor(target string, timeout time.Duration) error {
+	check, err := runner.readinessCheck(target)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(waitInterval).After(deadline) {
+			return fmt.Errorf("%s not ready after %v: %v", target, timeout, err)
+		}
+		time.Sleep(waitInterval)
+	}
+}
+
+func (runner *Runner) readinessCheck(target string) (func() error, error) {
+	switch {
+	case strings.HasPrefix(target, "/"):
+		uri := runner.url + target
+		return func() error {
+			resp, err := runner.client.Get(uri)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				return fmt.Errorf("got http response code %d", resp.StatusCode)
+			}
+			return nil
+		}, nil
+
+	case strings.HasPrefix(target, "tcp:"):
+		address := strings.TrimPrefix(target, "tcp:")
+		if !strings.Contains(address, ":") {
+			u, err := neturl.Parse(runner.url)
+			if err != nil {
+				return nil, fmt.Errorf("could not find the host to wait for in %v: %v", runner.url, err)
+			}
+			address = net.JoinHostPort(u.Hostname(), address)
+		}
+		return func() error {
+			conn, err := net.DialTimeout("tcp", address, waitInterval)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		}, nil
+
+	case strings.HasPrefix(target, "contract:"):
+		name := strings.TrimPrefix(target, "contract:")
+		for _, contract := range append(append([]Contract{}, runner.test.Setup...), runner.test.Contracts...) {
+			if contract.Name != name {
+				continue
+			}
+			contract := runner.prepare(contract)
+			// the variables can only come from the globals, the locals and the environment, which do not change
+			parsed := contract
+			if err := parseVariables(runner, &parsed); err != nil {
+				return nil, fmt.Errorf("invalid wait target %q: %v", target, err)
+			}
+			// the contract is run by a virtual user, so that its outputs and cookies are not kept by the runner
+			return func() error {
+				return runner.virtualUser().validateContract(contract, &ContractResult{Name: contract.Name})
+			}, nil
+		}
+		return nil, fmt.Errorf("invalid wait target %q: no contract named %v", target, name)
+	}
+
+	return nil, fmt.Errorf("invalid wait target %q: expected a path starting with /, tcp:PORT, tcp:HOST:PORT or contract:NAME", target)
+}
@@ -0,0 +1,108 @@
+package tester
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitFor(t *testing.T) {
+	defer func(interval time.Duration) { waitInterval = interval }(waitInterval)
+	waitInterval = time.Millisecond
+
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	test := &Test{Contracts: []Contract{{Name: "health", Path: "/health", Method: "GET", ExpectedHTTPCode: 200}}}
+	runner := NewRunner(server.URL, test)
+
+	assert.NoError(t, runner.WaitFor("/health", time.Second), "should wait for the path to be healthy")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+
+	atomic.StoreInt32(&count, 0)
+	assert.NoError(t, runner.WaitFor("contract:health", time.Second), "should wait for the contract to pass")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+
+	port := server.URL[strings.LastIndex(server.URL, ":")+1:]
+	assert.NoError(t, runner.WaitFor("tcp:"+port, time.Second), "should connect to the port on the host of the url")
+	assert.NoError(t, runner.WaitFor("tcp:"+strings.TrimPrefix(server.URL, "http://"), time.Second), "should connect to the host and port")
+}
+
+func TestWaitForContractLeavesNoState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"token": "abc"}`))
+	}))
+	defer server.Close()
+
+	test := &Test{Contracts: []Contract{{Name: "login", Path: "/login", ExpectedHTTPCode: 200, Outputs: map[string]string{"token": "JSON.token"}}}}
+	if !assert.NoError(t, test.init()) {
+		return
+	}
+	runner := NewRunner(server.URL, test)
+
+	assert.NoError(t, runner.WaitFor("contract:login", time.Second))
+	_, ok := runner.globals.get("token")
+	assert.False(t, ok, "the outputs of the contract waited for should not be kept")
+}
+
+func TestWaitForTimeout(t *testing.T) {
+	defer func(interval time.Duration) { waitInterval = interval }(waitInterval)
+	waitInterval = time.Millisecond
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	runner := NewRunner("http://"+address, &Test{})
+
+	err = runner.WaitFor("/health", 20*time.Millisecond)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "/health not ready after 20ms")
+	}
+	assert.Error(t, runner.WaitFor("tcp:"+address, 20*time.Millisecond))
+}
+
+func TestWaitForInvalidTarget(t *testing.T) {
+	runner := NewRunner("http://localhost", &Test{})
+
+	assert.Error(t, runner.WaitFor("health", time.Second))
+	assert.Error(t, runner.WaitFor("contract:missing", time.Second))
+}
+
+func TestWaitForSetupContract(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.Write([]byte(`{"token": "abc"}`))
+	}))
+	defer server.Close()
+
+	test := &Test{
+		Setup:     []Contract{{Name: "login", Path: "/login", ExpectedHTTPCode: 200, Outputs: map[string]string{"token": "JSON.token"}}},
+		Contracts: []Contract{{Name: "me", Path: "/me?token=::token::", ExpectedHTTPCode: 200}},
+	}
+	runner := NewRunner(server.URL, test)
+
+	assert.NoError(t, runner.WaitFor("contract:login", time.Second), "should wait for a setup contract")
+
+	// the outputs of the setup are not known before it runs, so the wait cannot succeed
+	atomic.StoreInt32(&count, 0)
+	start := time.Now()
+	assert.EqualError(t, runner.WaitFor("contract:me", time.Minute), `invalid wait target "contract:me": could not parse path: value for variable token not found`)
+	assert.True(t, time.Since(start) < time.Second, "should not wait for a contract which cannot pass")
+	assert.Equal(t, int32(0), atomic.LoadInt32(&count))
+}
@@ -0,0 +1,417 @@
+package tester
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// xpath is a compiled XPath expression, supporting the subset needed to select a value in an XML response:
+// child (/) and descendant (//) steps, element names or *, attributes (@name), text(), . and .., and predicates
+// on the position ([1], [last()]), on the presence or value of an attribute ([@id], [@id='1']) and on the text of
+// a child element or of the element itself ([name='x'], [text()='x']).  Namespace prefixes are ignored.
+type xpath struct {
+	expression string
+	steps      []xpathStep
+}
+
+type xpathAxis int
+
+const (
+	axisChild xpathAxis = iota
+	axisAttribute
+	axisText
+	axisSelf
+	axisParent
+)
+
+type xpathStep struct {
+	descendant bool
+	axis       xpathAxis
+	name       string
+	predicates []xpathPredicate
+}
+
+type xpathPredicate struct {
+	position int
+	last     bool
+
+	// attribute, child or text select the value compared to value, or whose presence is checked when value is nil
+	attribute string
+	child     string
+	text      bool
+	value     *string
+}
+
+func compileXPath(expression string) (*xpath, error) {
+	fail := func(format string, args ...interface{}) (*xpath, error) {
+		return nil, fmt.Errorf("invalid xpath %q: %s", expression, fmt.Sprintf(format, args...))
+	}
+
+	x := &xpath{expression: expression}
+	rest := expression
+	descendant := false
+	if strings.HasPrefix(rest, "//") {
+		descendant, rest = true, rest[2:]
+	} else if strings.HasPrefix(rest, "/") {
+		rest = rest[1:]
+	}
+
+	for {
+		if rest == "" {
+			return fail("expected a step")
+		}
+
+		step := xpathStep{descendant: descendant}
+		end := strings.IndexAny(rest, "[/")
+		if end < 0 {
+			end = len(rest)
+		}
+		name := rest[:end]
+		rest = rest[end:]
+
+		switch {
+		case name == ".":
+			step.axis = axisSelf
+		case name == "..":
+			step.axis = axisParent
+		case name == "text()":
+			step.axis = axisText
+		case strings.HasPrefix(name, "@"):
+			step.axis, step.name = axisAttribute, localName(name[1:])
+		default:
+			step.axis, step.name = axisChild, localName(name)
+		}
+		if (step.axis == axisChild || step.axis == axisAttribute) && !validXMLName(step.name) {
+			return fail("invalid name %q", name)
+		}
+
+		for strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return fail("missing ] in %q", rest)
+			}
+			p, err := compileXPathPredicate(strings.TrimSpace(rest[1:end]))
+			if err != nil {
+				return fail("%v", err)
+			}
+			step.predicates = append(step.predicates, p)
+			rest = rest[end+1:]
+		}
+
+		x.steps = append(x.steps, step)
+
+		switch {
+		case rest == "":
+			return x, nil
+		case strings.HasPrefix(rest, "//"):
+			descendant, rest = true, rest[2:]
+		case strings.HasPrefix(rest, "/"):
+			descendant, rest = false, rest[1:]
+		default:
+			return fail("unexpected %q", rest)
+		}
+	}
+}
+
+func compileXPathPredicate(s string) (xpathPredicate, error) {
+	var p xpathPredicate
+
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 {
+			return p, fmt.Errorf("positions start at 1, got %d", n)
+		}
+		p.position = n
+		return p, nil
+	}
+	if s == "last()" {
+		p.last = true
+		return p, nil
+	}
+
+	left := s
+	if i := strings.Index(s, "="); i >= 0 {
+		left = strings.TrimSpace(s[:i])
+		literal := strings.TrimSpace(s[i+1:])
+		switch {
+		case len(literal) >= 2 && (literal[0] == '\'' || literal[0] == '"') && literal[len(literal)-1] == literal[0]:
+			literal = literal[1 : len(literal)-1]
+		case literal != "" && strings.Trim(literal, "0123456789.-") == "":
+		default:
+			return p, fmt.Errorf("expected a quoted string or a number in predicate [%s]", s)
+		}
+		p.value = &literal
+	}
+
+	switch {
+	case left == "text()":
+		p.text = true
+	case strings.HasPrefix(left, "@") && validXMLName(localName(left[1:])):
+		p.attribute = localName(left[1:])
+	case validXMLName(localName(left)) && left != "*":
+		p.child = localName(left)
+	default:
+		return p, fmt.Errorf("unsupported predicate [%s]", s)
+	}
+
+	return p, nil
+}
+
+// localName removes the namespace prefix of a name
+func localName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+func validXMLName(name string) bool {
+	if name == "*" {
+		return true
+	}
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '-' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127) {
+			return false
+		}
+	}
+	return true
+}
+
+type xmlNodeKind int
+
+const (
+	xmlDocument xmlNodeKind = iota
+	xmlElement
+	xmlText
+	xmlAttribute
+)
+
+// xmlNode is a node of a parsed XML document
+type xmlNode struct {
+	kind     xmlNodeKind
+	name     string
+	value    string
+	attrs    []xml.Attr
+	children []*xmlNode
+	parent   *xmlNode
+}
+
+func parseXMLDocument(data []byte) (*xmlNode, error) {
+	doc := &xmlNode{kind: xmlDocument}
+	current := doc
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{kind: xmlElement, name: t.Name.Local, attrs: t.Attr, parent: current}
+			current.children = append(current.children, node)
+			current = node
+		case xml.EndElement:
+			if current.parent != nil {
+				current = current.parent
+			}
+		case xml.CharData:
+			if n := len(current.children); n > 0 && current.children[n-1].kind == xmlText {
+				current.children[n-1].value += string(t)
+			} else {
+				current.children = append(current.children, &xmlNode{kind: xmlText, value: string(t), parent: current})
+			}
+		}
+	}
+
+	for _, child := range doc.children {
+		if child.kind == xmlElement {
+			return doc, nil
+		}
+	}
+	return nil, fmt.Errorf("no root element")
+}
+
+// stringValue returns the text of a node, including the text of all its descendants for an element
+func (n *xmlNode) stringValue() string {
+	if n.kind == xmlText || n.kind == xmlAttribute {
+		return n.value
+	}
+	var b strings.Builder
+	for _, child := range n.children {
+		b.WriteString(child.stringValue())
+	}
+	return b.String()
+}
+
+func (n *xmlNode) attribute(name string) (string, bool) {
+	for _, attr := range n.attrs {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// descendantsOrSelf returns the node and all the elements it contains, in document order
+func (n *xmlNode) descendantsOrSelf() []*xmlNode {
+	nodes := []*xmlNode{n}
+	for _, child := range n.children {
+		if child.kind == xmlElement {
+			nodes = append(nodes, child.descendantsOrSelf()...)
+		}
+	}
+	return nodes
+}
+
+// evaluate returns the nodes selected by the expression in the document, in document order
+func (x *xpath) evaluate(doc *xmlNode) []*xmlNode {
+	context := []*xmlNode{doc}
+
+	for _, step := range x.steps {
+		var next []*xmlNode
+		seen := make(map[*xmlNode]bool)
+
+		for _, node := range context {
+			origins := []*xmlNode{node}
+			if step.descendant {
+				origins = node.descendantsOrSelf()
+			}
+
+			for _, origin := range origins {
+				for _, selected := range step.apply(origin) {
+					if !seen[selected] {
+						seen[selected] = true
+						next = append(next, selected)
+					}
+				}
+			}
+		}
+
+		context = next
+	}
+
+	return context
+}
+
+// apply returns the nodes selected by the step from a single node
+func (s xpathStep) apply(node *xmlNode) []*xmlNode {
+	var selected []*xmlNode
+
+	switch s.axis {
+	case axisSelf:
+		selected = []*xmlNode{node}
+	case axisParent:
+		if node.parent != nil {
+			selected = []*xmlNode{node.parent}
+		}
+	case axisText:
+		for _, child := range node.children {
+			if child.kind == xmlText {
+				selected = append(selected, child)
+			}
+		}
+	case axisAttribute:
+		for _, attr := range node.attrs {
+			if s.name == "*" || attr.Name.Local == s.name {
+				selected = append(selected, &xmlNode{kind: xmlAttribute, name: attr.Name.Local, value: attr.Value, parent: node})
+			}
+		}
+	case axisChild:
+		for _, child := range node.children {
+			if child.kind == xmlElement && (s.name == "*" || child.name == s.name) {
+				selected = append(selected, child)
+			}
+		}
+	}
+
+	for _, p := range s.predicates {
+		selected = p.filter(selected)
+	}
+
+	return selected
+}
+
+func (p xpathPredicate) filter(nodes []*xmlNode) []*xmlNode {
+	switch {
+	case p.position > 0:
+		if p.position > len(nodes) {
+			return nil
+		}
+		return nodes[p.position-1 : p.position]
+	case p.last:
+		if len(nodes) == 0 {
+			return nil
+		}
+		return nodes[len(nodes)-1:]
+	}
+
+	var filtered []*xmlNode
+	for _, node := range nodes {
+		if p.match(node) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+func (p xpathPredicate) match(node *xmlNode) bool {
+	var values []string
+
+	switch {
+	case p.attribute != "":
+		if value, ok := node.attribute(p.attribute); ok {
+			values = append(values, value)
+		}
+	case p.text:
+		values = append(values, node.stringValue())
+	case p.child != "":
+		for _, child := range node.children {
+			if child.kind == xmlElement && (p.child == "*" || child.name == p.child) {
+				values = append(values, child.stringValue())
+			}
+		}
+	}
+
+	if p.value == nil {
+		return len(values) > 0
+	}
+	for _, value := range values {
+		if strings.TrimSpace(value) == *p.value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseXML returns the text of the first node selected by an XPath expression in body, without surrounding spaces
+func parseXML(x *xpath, body []byte) (string, error) {
+	if len(body) == 0 {
+		return "", fmt.Errorf("no response body to parse")
+	}
+
+	doc, err := parseXMLDocument(body)
+	if err != nil {
+		return "", errors.Wrap(err, "could not parse response body as xml")
+	}
+
+	nodes := x.evaluate(doc)
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("xpath %q: no match in the response body", x.expression)
+	}
+
+	return strings.TrimSpace(nodes[0].stringValue()), nil
+}
@@ -0,0 +1,81 @@
+package tester
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const xpathTestDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns:atom="http://www.w3.org/2005/Atom">
+	<atom:title>Orders</atom:title>
+	<order id="1" status="shipped">
+		<item sku="a">apple</item>
+		<item sku="b">banana</item>
+		<total>3.50</total>
+	</order>
+	<order id="2" status="pending">
+		<item sku="c">cherry</item>
+		<total>12</total>
+		<note>call <b>before</b> delivery</note>
+	</order>
+</feed>`
+
+func TestXPath(t *testing.T) {
+	tests := []struct {
+		description string
+		expression  string
+		expected    string
+		err         bool
+	}{
+		{description: "absolute path", expression: "/feed/order/total", expected: "3.50"},
+		{description: "relative path", expression: "feed/order/total", expected: "3.50"},
+		{description: "namespace prefix", expression: "/feed/atom:title", expected: "Orders"},
+		{description: "descendant", expression: "//item", expected: "apple"},
+		{description: "attribute", expression: "//order/@status", expected: "shipped"},
+		{description: "position", expression: "/feed/order[2]/@id", expected: "2"},
+		{description: "last", expression: "//order[last()]/item/@sku", expected: "c"},
+		{description: "position within each parent", expression: "//order/item[1]", expected: "apple"},
+		{description: "attribute value", expression: "//order[@status='pending']/total", expected: "12"},
+		{description: "attribute presence", expression: "//*[@sku]", expected: "apple"},
+		{description: "child value", expression: `//order[total="12"]/@id`, expected: "2"},
+		{description: "text value", expression: "//item[text()='banana']/@sku", expected: "b"},
+		{description: "text", expression: "//note/text()", expected: "call"},
+		{description: "string value of an element", expression: "//note", expected: "call before delivery"},
+		{description: "parent", expression: "//item[@sku='c']/../@id", expected: "2"},
+		{description: "wildcard", expression: "/feed/*[3]/@id", expected: "2"},
+		{description: "no match", expression: "//order[@id='3']", err: true},
+	}
+
+	for _, test := range tests {
+		x, err := compileXPath(test.expression)
+		if !assert.NoError(t, err, test.description) {
+			continue
+		}
+
+		value, err := parseXML(x, []byte(xpathTestDocument))
+		if test.err {
+			assert.Error(t, err, test.description)
+			continue
+		}
+		assert.NoError(t, err, test.description)
+		assert.Equal(t, test.expected, value, test.description)
+	}
+}
+
+func TestCompileXPathErrors(t *testing.T) {
+	for _, expression := range []string{"", "/", "//order/", "/order[", "/order[0]", "/order[@id=x]", "/order[count(item)]", "/or der"} {
+		_, err := compileXPath(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestParseXMLErrors(t *testing.T) {
+	x, _ := compileXPath("/a")
+
+	_, err := parseXML(x, nil)
+	assert.EqualError(t, err, "no response body to parse")
+
+	_, err = parseXML(x, []byte(`{"a": 1}`))
+	assert.Error(t, err)
+}